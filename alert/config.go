@@ -0,0 +1,83 @@
+package alert
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// durationConfig reads a duration from config given as a Go duration string or a number of seconds.
+func durationConfig(config map[string]any, key string, def time.Duration) (time.Duration, error) {
+	switch v := config[key].(type) {
+	case nil:
+		return def, nil
+	case string:
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return 0, fmt.Errorf("invalid %s: %w", key, err)
+		}
+		return d, nil
+	case float64:
+		return time.Duration(v * float64(time.Second)), nil
+	case int:
+		return time.Duration(v) * time.Second, nil
+	default:
+		return 0, fmt.Errorf("invalid %s: expected duration string", key)
+	}
+}
+
+// stringListConfig reads a list of strings from config given as a JSON array
+// or a comma-separated string. Empty entries are dropped.
+func stringListConfig(config map[string]any, key string) ([]string, error) {
+	var raw []string
+	switch v := config[key].(type) {
+	case nil:
+		return nil, nil
+	case string:
+		raw = strings.Split(v, ",")
+	case []string:
+		raw = v
+	case []any:
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid %s: expected list of strings", key)
+			}
+			raw = append(raw, s)
+		}
+	default:
+		return nil, fmt.Errorf("invalid %s: expected list of strings", key)
+	}
+
+	values := make([]string, 0, len(raw))
+	for _, s := range raw {
+		if s = strings.TrimSpace(s); s != "" {
+			values = append(values, s)
+		}
+	}
+	return values, nil
+}
+
+// intConfig reads an integer from config given as a JSON number or a string.
+func intConfig(config map[string]any, key string, def int) (int, error) {
+	switch v := config[key].(type) {
+	case nil:
+		return def, nil
+	case int:
+		return v, nil
+	case float64:
+		if v != float64(int(v)) {
+			return 0, fmt.Errorf("invalid %s: expected integer", key)
+		}
+		return int(v), nil
+	case string:
+		n, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil {
+			return 0, fmt.Errorf("invalid %s: %w", key, err)
+		}
+		return n, nil
+	default:
+		return 0, fmt.Errorf("invalid %s: expected integer", key)
+	}
+}
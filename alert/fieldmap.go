@@ -0,0 +1,153 @@
+package alert
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/opsorch/opsorch-core/schema"
+)
+
+// Default sources used for fields that have no fieldMapping entry.
+var defaultFieldSources = map[string][]string{
+	"title":        {"labels.alertname"},
+	"description":  {"annotations.description"},
+	"service":      {"labels.service"},
+	"runbookURL":   {"annotations.runbook_url"},
+	"dashboardURL": {"annotations.dashboard_url"},
+}
+
+// fieldSource is one candidate for an alert field: a label, an annotation or a template.
+type fieldSource struct {
+	label      string
+	annotation string
+	tmpl       *template.Template
+}
+
+// fieldTemplateData is the data available to fieldMapping templates.
+type fieldTemplateData struct {
+	Labels      map[string]string
+	Annotations map[string]string
+	Fingerprint string
+	Status      string
+}
+
+// fieldMapping maps alert labels and annotations onto alert fields using
+// ordered fallback lists; the first source yielding a non-empty value wins.
+type fieldMapping struct {
+	sources map[string][]fieldSource
+}
+
+// newFieldMappingFromConfig reads the fieldMapping config object. Each entry
+// is a source or list of sources: "labels.<name>", "annotations.<name>" or a
+// Go text/template such as "{{ .Labels.alertname }} on {{ .Labels.instance }}".
+func newFieldMappingFromConfig(config map[string]any) (fieldMapping, error) {
+	raw := map[string][]string{}
+	for field, sources := range defaultFieldSources {
+		raw[field] = sources
+	}
+
+	if cfg, ok := config["fieldMapping"]; ok && cfg != nil {
+		entries, ok := cfg.(map[string]any)
+		if !ok {
+			return fieldMapping{}, fmt.Errorf("invalid fieldMapping: expected object")
+		}
+		for field := range entries {
+			if _, known := defaultFieldSources[field]; !known {
+				return fieldMapping{}, fmt.Errorf("invalid fieldMapping: unknown field %q", field)
+			}
+			// A single string is one source, so templates may contain commas.
+			if source, ok := entries[field].(string); ok {
+				raw[field] = []string{source}
+				continue
+			}
+			sources, err := stringListConfig(entries, field)
+			if err != nil {
+				return fieldMapping{}, fmt.Errorf("invalid fieldMapping: %w", err)
+			}
+			raw[field] = sources
+		}
+	}
+
+	mapping := fieldMapping{sources: make(map[string][]fieldSource, len(raw))}
+	for field, sources := range raw {
+		for _, source := range sources {
+			parsed, err := parseFieldSource(field, source)
+			if err != nil {
+				return fieldMapping{}, err
+			}
+			mapping.sources[field] = append(mapping.sources[field], parsed)
+		}
+	}
+	return mapping, nil
+}
+
+func parseFieldSource(field, source string) (fieldSource, error) {
+	switch {
+	case strings.Contains(source, "{{"):
+		tmpl, err := template.New(field).Option("missingkey=zero").Parse(source)
+		if err != nil {
+			return fieldSource{}, fmt.Errorf("invalid fieldMapping template for %s: %w", field, err)
+		}
+		return fieldSource{tmpl: tmpl}, nil
+	case strings.HasPrefix(source, "labels."):
+		return fieldSource{label: strings.TrimPrefix(source, "labels.")}, nil
+	case strings.HasPrefix(source, "annotations."):
+		return fieldSource{annotation: strings.TrimPrefix(source, "annotations.")}, nil
+	default:
+		return fieldSource{}, fmt.Errorf("invalid fieldMapping source for %s: %q", field, source)
+	}
+}
+
+// resolve returns the first non-empty value for a field.
+func (m fieldMapping) resolve(field string, data fieldTemplateData) string {
+	sources, ok := m.sources[field]
+	if !ok {
+		// Zero-value mappings fall back to the default sources.
+		for _, source := range defaultFieldSources[field] {
+			parsed, _ := parseFieldSource(field, source)
+			sources = append(sources, parsed)
+		}
+	}
+
+	for _, source := range sources {
+		var value string
+		switch {
+		case source.tmpl != nil:
+			var b strings.Builder
+			if err := source.tmpl.Execute(&b, data); err != nil {
+				continue
+			}
+			value = strings.TrimSpace(b.String())
+		case source.label != "":
+			value = data.Labels[source.label]
+		default:
+			value = data.Annotations[source.annotation]
+		}
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// apply sets the mapped Title, Description, Service, runbook and dashboard
+// fields on an alert. The alert's Fields map must be initialized.
+func (m fieldMapping) apply(alert *schema.Alert, labels, annotations map[string]string) {
+	data := fieldTemplateData{
+		Labels:      labels,
+		Annotations: annotations,
+		Fingerprint: alert.ID,
+		Status:      alert.Status,
+	}
+
+	alert.Title = m.resolve("title", data)
+	alert.Description = m.resolve("description", data)
+	alert.Service = m.resolve("service", data)
+	if runbook := m.resolve("runbookURL", data); runbook != "" {
+		alert.Fields["runbookURL"] = runbook
+	}
+	if dashboard := m.resolve("dashboardURL", data); dashboard != "" {
+		alert.Fields["dashboardURL"] = dashboard
+	}
+}
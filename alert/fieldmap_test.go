@@ -0,0 +1,72 @@
+package alert
+
+import (
+	"testing"
+
+	"github.com/opsorch/opsorch-core/schema"
+)
+
+func TestFieldMappingApply(t *testing.T) {
+	mapping, err := newFieldMappingFromConfig(map[string]any{
+		"fieldMapping": map[string]any{
+			"title":       "{{ .Labels.alertname }} on {{ .Labels.instance }}{{ if .Labels.job }}, {{ .Labels.job }}{{ end }}",
+			"description": []any{"annotations.description", "annotations.summary", "annotations.message"},
+			"service":     []string{"labels.service", "labels.app"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	alert := schema.Alert{ID: "abc123", Fields: map[string]any{}}
+	mapping.apply(&alert,
+		map[string]string{"alertname": "HighCPU", "instance": "node-1", "app": "checkout"},
+		map[string]string{"summary": "CPU is high", "runbook_url": "https://runbooks/cpu", "dashboard_url": "https://grafana/cpu"},
+	)
+
+	if alert.Title != "HighCPU on node-1" {
+		t.Errorf("Title = %v, want HighCPU on node-1", alert.Title)
+	}
+	if alert.Description != "CPU is high" {
+		t.Errorf("Description = %v, want summary fallback", alert.Description)
+	}
+	if alert.Service != "checkout" {
+		t.Errorf("Service = %v, want app label fallback", alert.Service)
+	}
+	if alert.Fields["runbookURL"] != "https://runbooks/cpu" || alert.Fields["dashboardURL"] != "https://grafana/cpu" {
+		t.Errorf("Fields = %v, want runbookURL and dashboardURL", alert.Fields)
+	}
+}
+
+func TestFieldMappingDefaults(t *testing.T) {
+	var zero fieldMapping
+	alert := schema.Alert{Fields: map[string]any{}}
+	zero.apply(&alert, map[string]string{"alertname": "HighCPU", "service": "api"}, map[string]string{"description": "desc"})
+
+	if alert.Title != "HighCPU" || alert.Service != "api" || alert.Description != "desc" {
+		t.Errorf("alert = %+v, want default mapping", alert)
+	}
+	if _, ok := alert.Fields["runbookURL"]; ok {
+		t.Error("expected no runbookURL without a runbook_url annotation")
+	}
+}
+
+func TestNewFieldMappingFromConfigErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		config map[string]any
+	}{
+		{name: "not an object", config: map[string]any{"fieldMapping": "title"}},
+		{name: "unknown field", config: map[string]any{"fieldMapping": map[string]any{"owner": "labels.team"}}},
+		{name: "bad source", config: map[string]any{"fieldMapping": map[string]any{"title": "alertname"}}},
+		{name: "bad template", config: map[string]any{"fieldMapping": map[string]any{"title": "{{ .Labels.alertname"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := newFieldMappingFromConfig(tt.config); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
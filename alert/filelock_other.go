@@ -0,0 +1,9 @@
+//go:build !unix
+
+package alert
+
+// lockFile is a no-op where advisory file locks are unavailable; updates are
+// then only serialized within one process.
+func lockFile(path string) (func(), error) {
+	return func() {}, nil
+}
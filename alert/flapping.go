@@ -0,0 +1,136 @@
+package alert
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/opsorch/opsorch-core/schema"
+)
+
+// flapDetector counts firing/resolved transitions per fingerprint within a
+// sliding window and flags alerts whose count reaches the threshold.
+type flapDetector struct {
+	mu        sync.Mutex
+	window    time.Duration
+	threshold int
+	states    map[string]*flapState
+	now       func() time.Time
+}
+
+type flapState struct {
+	active      bool
+	pending     bool
+	startsAt    time.Time
+	lastSeen    time.Time
+	transitions []time.Time
+}
+
+// newFlapDetectorFromConfig reads flapThreshold and flapWindow. It returns nil
+// when flapThreshold is unset, which disables flapping detection.
+func newFlapDetectorFromConfig(config map[string]any) (*flapDetector, error) {
+	threshold, err := intConfig(config, "flapThreshold", 0)
+	if err != nil {
+		return nil, err
+	}
+	if threshold == 0 {
+		return nil, nil
+	}
+	if threshold < 0 {
+		return nil, fmt.Errorf("invalid flapThreshold: %d", threshold)
+	}
+
+	window, err := durationConfig(config, "flapWindow", time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	if window <= 0 {
+		return nil, fmt.Errorf("invalid flapWindow: %s", window)
+	}
+
+	return newFlapDetector(window, threshold), nil
+}
+
+func newFlapDetector(window time.Duration, threshold int) *flapDetector {
+	return &flapDetector{
+		window:    window,
+		threshold: threshold,
+		states:    map[string]*flapState{},
+		now:       time.Now,
+	}
+}
+
+// observe records the current state of each alert and sets Fields["flapping"]
+// and Metadata["transitionCount"]. A new startsAt for a fingerprint that was
+// active counts as a missed resolve followed by a new firing.
+func (d *flapDetector) observe(alerts []schema.Alert) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+	cutoff := now.Add(-d.window)
+	for i := range alerts {
+		alert := &alerts[i]
+		active := alertActive(*alert, now)
+		pending := alert.Status == "pending"
+
+		state, ok := d.states[alert.ID]
+		if !ok {
+			state = &flapState{active: active, pending: pending, startsAt: alert.CreatedAt}
+			d.states[alert.ID] = state
+		} else {
+			switch {
+			case state.active != active:
+				state.transitions = append(state.transitions, now)
+			case active && !pending && !state.pending && alert.CreatedAt.After(state.startsAt):
+				// Pending rule alerts start at activeAt rather than startsAt,
+				// so only compare start times between firing alerts.
+				state.transitions = append(state.transitions, now, now)
+			}
+			state.active = active
+			state.pending = pending
+			if alert.CreatedAt.After(state.startsAt) {
+				state.startsAt = alert.CreatedAt
+			}
+		}
+		state.lastSeen = now
+		state.transitions = pruneBefore(state.transitions, cutoff)
+
+		// Copy before writing: the maps may be shared with tracked state.
+		alert.Fields = cloneMap(alert.Fields)
+		if alert.Fields == nil {
+			alert.Fields = map[string]any{}
+		}
+		alert.Metadata = cloneMap(alert.Metadata)
+		if alert.Metadata == nil {
+			alert.Metadata = map[string]any{}
+		}
+		alert.Fields["flapping"] = len(state.transitions) >= d.threshold
+		alert.Metadata["transitionCount"] = len(state.transitions)
+	}
+
+	// Forget fingerprints that have not been seen for a whole window.
+	for id, state := range d.states {
+		if state.lastSeen.Before(cutoff) {
+			delete(d.states, id)
+		}
+	}
+}
+
+// alertActive reports whether an alert is firing, treating an endsAt in the
+// past as resolved like the state tracker does.
+func alertActive(alert schema.Alert, now time.Time) bool {
+	if alert.Status == "resolved" {
+		return false
+	}
+	endsAt, ok := alert.Fields["endsAt"].(time.Time)
+	return !ok || endsAt.After(now)
+}
+
+func pruneBefore(times []time.Time, cutoff time.Time) []time.Time {
+	i := 0
+	for i < len(times) && times[i].Before(cutoff) {
+		i++
+	}
+	return times[i:]
+}
@@ -0,0 +1,80 @@
+package alert
+
+import (
+	"testing"
+	"time"
+
+	"github.com/opsorch/opsorch-core/schema"
+)
+
+func TestFlapDetector(t *testing.T) {
+	now := time.Date(2025, 12, 3, 10, 0, 0, 0, time.UTC)
+	d := newFlapDetector(30*time.Minute, 3)
+	d.now = func() time.Time { return now }
+
+	observe := func(status string, startsAt time.Time) schema.Alert {
+		alerts := []schema.Alert{{ID: "abc123", Status: status, CreatedAt: startsAt, Fields: map[string]any{}}}
+		d.observe(alerts)
+		now = now.Add(5 * time.Minute)
+		return alerts[0]
+	}
+
+	first := now
+	if alert := observe("firing", first); alert.Fields["flapping"] != false || alert.Metadata["transitionCount"] != 0 {
+		t.Fatalf("first observation = %+v", alert)
+	}
+	observe("resolved", first)
+	refired := now
+	observe("firing", refired)
+	alert := observe("firing", refired)
+	if alert.Metadata["transitionCount"] != 2 || alert.Fields["flapping"] != false {
+		t.Errorf("after refiring: transitionCount = %v, flapping = %v", alert.Metadata["transitionCount"], alert.Fields["flapping"])
+	}
+
+	// A new startsAt while still firing counts as a missed resolve and a new firing.
+	alert = observe("firing", now)
+	if alert.Metadata["transitionCount"] != 4 || alert.Fields["flapping"] != true {
+		t.Errorf("after flapping: transitionCount = %v, flapping = %v", alert.Metadata["transitionCount"], alert.Fields["flapping"])
+	}
+
+	// Transitions age out of the window.
+	now = now.Add(time.Hour)
+	alert = observe("firing", first.Add(10*time.Minute))
+	if alert.Metadata["transitionCount"] != 0 || alert.Fields["flapping"] != false {
+		t.Errorf("after window: transitionCount = %v, flapping = %v", alert.Metadata["transitionCount"], alert.Fields["flapping"])
+	}
+}
+
+func TestFlapDetectorIgnoresPending(t *testing.T) {
+	now := time.Date(2025, 12, 3, 10, 0, 0, 0, time.UTC)
+	d := newFlapDetector(time.Hour, 1)
+	d.now = func() time.Time { return now }
+
+	pending := []schema.Alert{{ID: "abc123", Status: "pending", CreatedAt: now}}
+	d.observe(pending)
+	firing := []schema.Alert{{ID: "abc123", Status: "firing", CreatedAt: now.Add(5 * time.Minute)}}
+	d.observe(firing)
+
+	if firing[0].Fields["flapping"] != false {
+		t.Errorf("pending to firing counted as flapping: %v", firing[0].Metadata["transitionCount"])
+	}
+}
+
+func TestNewFlapDetectorFromConfig(t *testing.T) {
+	d, err := newFlapDetectorFromConfig(map[string]any{})
+	if err != nil || d != nil {
+		t.Errorf("expected flapping detection disabled by default, got %v, %v", d, err)
+	}
+
+	d, err = newFlapDetectorFromConfig(map[string]any{"flapThreshold": float64(4), "flapWindow": "15m"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if d.threshold != 4 || d.window != 15*time.Minute {
+		t.Errorf("threshold = %d, window = %s", d.threshold, d.window)
+	}
+
+	if _, err := newFlapDetectorFromConfig(map[string]any{"flapThreshold": 2.5}); err == nil {
+		t.Error("expected error for fractional flapThreshold")
+	}
+}
@@ -0,0 +1,72 @@
+package alert
+
+import (
+	"context"
+
+	"github.com/opsorch/opsorch-core/schema"
+)
+
+// AlertGroup is an Alertmanager alert group expressed with OpsOrch alerts.
+type AlertGroup struct {
+	Labels   map[string]string `json:"labels"`
+	Receiver string            `json:"receiver"`
+	Alerts   []schema.Alert    `json:"alerts"`
+}
+
+// alertmanagerGroup represents an alert group from the Alertmanager API.
+type alertmanagerGroup struct {
+	Labels   map[string]string    `json:"labels"`
+	Receiver alertmanagerReceiver `json:"receiver"`
+	Alerts   []alertmanagerAlert  `json:"alerts"`
+}
+
+// Groups fetches alerts grouped by receiver and group_by labels from Prometheus Alertmanager.
+// The query filters are applied to the member alerts; Limit caps the number of groups returned.
+func (p *PrometheusAlertProvider) Groups(ctx context.Context, query schema.AlertQuery) ([]AlertGroup, error) {
+	var amGroups []alertmanagerGroup
+	if err := p.getJSON(ctx, "/api/v2/alerts/groups", p.buildAlertFilters(query), &amGroups); err != nil {
+		return nil, err
+	}
+
+	groups := make([]AlertGroup, 0, len(amGroups))
+	for _, amGroup := range amGroups {
+		group := p.convertAlertmanagerGroup(amGroup)
+		if len(query.Statuses) > 0 || len(query.Severities) > 0 {
+			// Drop members whose status or normalized severity was not requested
+			alerts := group.Alerts[:0]
+			for _, alert := range group.Alerts {
+				if matchesStatus(alert, query.Statuses) && matchesSeverity(alert, query.Severities) {
+					alerts = append(alerts, alert)
+				}
+			}
+			if len(alerts) == 0 {
+				continue
+			}
+			group.Alerts = alerts
+		}
+		groups = append(groups, group)
+	}
+
+	if query.Limit > 0 && query.Limit < len(groups) {
+		groups = groups[:query.Limit]
+	}
+
+	return groups, nil
+}
+
+func (p *PrometheusAlertProvider) convertAlertmanagerGroup(amGroup alertmanagerGroup) AlertGroup {
+	group := AlertGroup{
+		Labels:   amGroup.Labels,
+		Receiver: amGroup.Receiver.Name,
+		Alerts:   make([]schema.Alert, 0, len(amGroup.Alerts)),
+	}
+	if group.Labels == nil {
+		group.Labels = map[string]string{}
+	}
+
+	for _, amAlert := range amGroup.Alerts {
+		group.Alerts = append(group.Alerts, p.convertAlertmanagerAlert(amAlert))
+	}
+
+	return group
+}
@@ -0,0 +1,91 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/opsorch/opsorch-core/schema"
+)
+
+func TestGroups(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v2/alerts/groups" && r.Method == "GET" {
+			if got := r.URL.Query()["filter"]; len(got) != 1 || got[0] != `service="api"` {
+				t.Errorf("filter = %v, want [service=\"api\"]", got)
+			}
+			w.WriteHeader(http.StatusOK)
+			json.NewEncoder(w).Encode([]map[string]any{
+				{
+					"labels":   map[string]string{"alertname": "HighCPU"},
+					"receiver": map[string]string{"name": "oncall"},
+					"alerts": []map[string]any{
+						{
+							"fingerprint": "abc123",
+							"status":      map[string]string{"state": "active"},
+							"labels": map[string]string{
+								"alertname": "HighCPU",
+								"severity":  "critical",
+								"service":   "api",
+							},
+							"annotations": map[string]string{},
+							"startsAt":    "2025-12-03T10:00:00Z",
+							"updatedAt":   "2025-12-03T10:05:00Z",
+						},
+					},
+				},
+				{
+					"labels":   map[string]string{},
+					"receiver": map[string]string{"name": "default"},
+					"alerts":   []map[string]any{},
+				},
+			})
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	prov := &PrometheusAlertProvider{
+		baseURL: server.URL,
+		client:  &http.Client{},
+	}
+
+	groups, err := prov.Groups(context.Background(), schema.AlertQuery{
+		Scope: schema.QueryScope{Service: "api"},
+	})
+	if err != nil {
+		t.Fatalf("Groups() error = %v", err)
+	}
+
+	if len(groups) != 2 {
+		t.Fatalf("expected 2 groups, got %d", len(groups))
+	}
+
+	group := groups[0]
+	if group.Receiver != "oncall" {
+		t.Errorf("Receiver = %v, want oncall", group.Receiver)
+	}
+	if group.Labels["alertname"] != "HighCPU" {
+		t.Errorf("Labels = %v, want alertname=HighCPU", group.Labels)
+	}
+	if len(group.Alerts) != 1 || group.Alerts[0].ID != "abc123" {
+		t.Fatalf("Alerts = %+v, want single alert abc123", group.Alerts)
+	}
+	if group.Alerts[0].Status != "firing" {
+		t.Errorf("Status = %v, want firing", group.Alerts[0].Status)
+	}
+
+	groups, err = prov.Groups(context.Background(), schema.AlertQuery{
+		Scope: schema.QueryScope{Service: "api"},
+		Limit: 1,
+	})
+	if err != nil {
+		t.Fatalf("Groups() error = %v", err)
+	}
+	if len(groups) != 1 {
+		t.Errorf("expected limit to cap groups at 1, got %d", len(groups))
+	}
+}
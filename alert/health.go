@@ -0,0 +1,143 @@
+package alert
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/opsorch/opsorch-prometheus-adapter/httptransport"
+	"github.com/opsorch/opsorch-prometheus-adapter/logging"
+)
+
+// configKeys are the config fields read by NewPrometheusAlertProvider. The
+// webhook fields are read by NewWebhookHandler.
+var configKeys = []string{
+	"alertmanagerURL",
+	"alertmanagerURLs",
+	"alertmanagerMode",
+	"defaultSeverity",
+	"severityLabels",
+	"severityMapping",
+	"fieldMapping",
+	"externalURL",
+	"alertLinkMode",
+	"allowWrite",
+	"stateStore",
+	"statePath",
+	"stateRetention",
+	"prometheusURL",
+	"seriesEnrichment",
+	"seriesLookback",
+	"flapThreshold",
+	"flapWindow",
+	logging.KeyLevel,
+}
+
+// ConfigKeys returns the config fields the alert provider reads, including
+// the auth and TLS fields read by httptransport.
+func ConfigKeys() []string {
+	return append(append([]string(nil), configKeys...), httptransport.Keys()...)
+}
+
+// BackendHealth is the health of one Alertmanager or Prometheus server.
+type BackendHealth struct {
+	URL     string `json:"url"`
+	Ready   bool   `json:"ready"`
+	Version string `json:"version,omitempty"`
+	// Cluster is the Alertmanager cluster status: ready, settling or disabled.
+	Cluster string `json:"cluster,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Health is the health of the backends behind the provider.
+type Health struct {
+	// Ready is set when at least one Alertmanager peer and, if configured,
+	// Prometheus are ready.
+	Ready         bool            `json:"ready"`
+	Alertmanagers []BackendHealth `json:"alertmanagers"`
+	Prometheus    *BackendHealth  `json:"prometheus,omitempty"`
+}
+
+// Health checks every Alertmanager peer through /api/v2/status, updating the
+// cached peer health, and Prometheus through /-/ready and
+// /api/v1/status/buildinfo when prometheusURL is set.
+func (p *PrometheusAlertProvider) Health(ctx context.Context) Health {
+	urls := p.peerURLs()
+	health := Health{Alertmanagers: make([]BackendHealth, len(urls))}
+
+	var wg sync.WaitGroup
+	for i, base := range urls {
+		wg.Add(1)
+		go func(i int, base string) {
+			defer wg.Done()
+			health.Alertmanagers[i] = p.alertmanagerHealth(ctx, base)
+		}(i, base)
+	}
+	if p.rulesAPI != nil {
+		prometheus := p.prometheusHealth(ctx)
+		health.Prometheus = &prometheus
+	}
+	wg.Wait()
+
+	for _, am := range health.Alertmanagers {
+		health.Ready = health.Ready || am.Ready
+	}
+	if health.Prometheus != nil && !health.Prometheus.Ready {
+		health.Ready = false
+	}
+	return health
+}
+
+func (p *PrometheusAlertProvider) alertmanagerHealth(ctx context.Context, base string) BackendHealth {
+	var status struct {
+		Cluster struct {
+			Status string `json:"status"`
+		} `json:"cluster"`
+		VersionInfo struct {
+			Version string `json:"version"`
+		} `json:"versionInfo"`
+	}
+	err := p.getJSONFrom(ctx, base, "/api/v2/status", nil, &status)
+	p.markPeer(base, err == nil)
+	if err != nil {
+		return BackendHealth{URL: base, Error: err.Error()}
+	}
+	return BackendHealth{
+		URL:     base,
+		Ready:   true,
+		Version: status.VersionInfo.Version,
+		Cluster: status.Cluster.Status,
+	}
+}
+
+func (p *PrometheusAlertProvider) prometheusHealth(ctx context.Context) BackendHealth {
+	health := BackendHealth{URL: p.prometheusURL}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", p.prometheusURL+"/-/ready", nil)
+	if err != nil {
+		health.Error = fmt.Sprintf("create request: %v", err)
+		return health
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		health.Error = fmt.Sprintf("execute request: %v", err)
+		return health
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		health.Error = fmt.Sprintf("prometheus not ready: %d %s", resp.StatusCode, strings.TrimSpace(string(body)))
+		return health
+	}
+	health.Ready = true
+
+	// Build info is best effort, since Prometheus-compatible backends do not
+	// all serve it.
+	if info, err := p.rulesAPI.Buildinfo(ctx); err == nil {
+		health.Version = info.Version
+	}
+	return health
+}
@@ -0,0 +1,59 @@
+package alert
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHealth(t *testing.T) {
+	up := newPeerServer(t, http.StatusOK, nil)
+	defer up.Close()
+	down := newPeerServer(t, http.StatusBadGateway, nil)
+	defer down.Close()
+
+	prometheusReady := true
+	prometheus := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/-/ready":
+			if !prometheusReady {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+		case "/api/v1/status/buildinfo":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"status":"success","data":{"version":"2.53.0"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer prometheus.Close()
+
+	created, err := NewPrometheusAlertProvider(map[string]any{
+		"alertmanagerURLs": []any{up.URL, down.URL},
+		"prometheusURL":    prometheus.URL,
+	})
+	if err != nil {
+		t.Fatalf("NewPrometheusAlertProvider() error = %v", err)
+	}
+	prov := created.(*PrometheusAlertProvider)
+
+	health := prov.Health(context.Background())
+	if !health.Ready || len(health.Alertmanagers) != 2 {
+		t.Fatalf("Health() = %+v, want ready with 2 peers", health)
+	}
+	if am := health.Alertmanagers[0]; !am.Ready || am.Cluster != "ready" || am.URL != up.URL {
+		t.Errorf("peer 0 = %+v, want ready", am)
+	}
+	if am := health.Alertmanagers[1]; am.Ready || am.Error == "" {
+		t.Errorf("peer 1 = %+v, want unhealthy with error", am)
+	}
+	if health.Prometheus == nil || !health.Prometheus.Ready || health.Prometheus.Version != "2.53.0" {
+		t.Errorf("prometheus = %+v, want ready 2.53.0", health.Prometheus)
+	}
+
+	prometheusReady = false
+	if health := prov.Health(context.Background()); health.Ready || health.Prometheus.Ready {
+		t.Errorf("Health() = %+v, want not ready when Prometheus is not ready", health)
+	}
+}
@@ -0,0 +1,267 @@
+package alert
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-prometheus-adapter/apierr"
+)
+
+// Sort fields and orders supported by QueryOptions.
+const (
+	SortBySeverity  = "severity"
+	SortByStartsAt  = "startsAt"
+	SortByUpdatedAt = "updatedAt"
+
+	OrderAsc  = "asc"
+	OrderDesc = "desc"
+)
+
+// severityRank orders the common severities from least to most severe;
+// unknown severities sort below info.
+var severityRank = map[string]int{
+	"info":     1,
+	"warning":  2,
+	"error":    3,
+	"critical": 4,
+}
+
+// QueryOptions controls sorting, pagination and time-window filtering of an
+// alert query. AlertQuery.Limit is the page size.
+type QueryOptions struct {
+	// SortBy is severity, startsAt or updatedAt. Defaults to startsAt.
+	SortBy string `json:"sortBy,omitempty"`
+	// Order is asc or desc. Defaults to desc: most severe or newest first.
+	Order string `json:"order,omitempty"`
+	// Offset skips this many alerts. Ignored when Cursor is set.
+	Offset int `json:"offset,omitempty"`
+	// Cursor is the NextCursor of a previous page.
+	Cursor string `json:"cursor,omitempty"`
+	// Start and End bound TimeField, inclusively. Zero values are unbounded.
+	Start time.Time `json:"start,omitempty"`
+	End   time.Time `json:"end,omitempty"`
+	// TimeField is startsAt or updatedAt. Defaults to startsAt.
+	TimeField string `json:"timeField,omitempty"`
+	// Search is a free-text search over title, description, labels and
+	// annotations, e.g. `instance:node-1 "disk full"`.
+	Search string `json:"search,omitempty"`
+}
+
+// AlertPage is one page of alerts along with the total number of matches.
+type AlertPage struct {
+	Alerts     []schema.Alert `json:"alerts"`
+	Total      int            `json:"total"`
+	NextCursor string         `json:"nextCursor,omitempty"`
+	// Warnings name sources that could not be read, such as a failed
+	// Prometheus rules query, so the page may be incomplete.
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// QueryPage fetches alerts matching the query and search, sorts them stably and returns
+// the requested page. Options left unset in opts are read from query.Metadata.
+// Cursors encode an offset into the sorted result, so pages can shift if
+// alerts start or resolve between requests.
+func (p *PrometheusAlertProvider) QueryPage(ctx context.Context, query schema.AlertQuery, opts QueryOptions) (AlertPage, error) {
+	fromMetadata, err := metadataOptions(query)
+	if err != nil {
+		return AlertPage{}, apierr.Wrap(apierr.InvalidArgument, err, nil)
+	}
+	opts = opts.withDefaults(fromMetadata)
+	offset, err := opts.validate()
+	if err != nil {
+		return AlertPage{}, apierr.Wrap(apierr.InvalidArgument, err, nil)
+	}
+	search, err := compileSearch(strings.TrimSpace(querySearch(query) + " " + opts.Search))
+	if err != nil {
+		return AlertPage{}, apierr.Wrap(apierr.InvalidArgument, err, nil)
+	}
+
+	matched, warnings, err := p.matchingAlerts(ctx, query)
+	if err != nil {
+		return AlertPage{}, err
+	}
+
+	alerts := make([]schema.Alert, 0, len(matched))
+	for _, alert := range matched {
+		if opts.inWindow(alert) && search.matches(alert) {
+			alerts = append(alerts, alert)
+		}
+	}
+	sortAlerts(alerts, opts.SortBy, opts.Order)
+
+	page := AlertPage{Total: len(alerts), Warnings: warnings}
+	if offset > len(alerts) {
+		offset = len(alerts)
+	}
+	end := len(alerts)
+	if query.Limit > 0 && offset+query.Limit < end {
+		end = offset + query.Limit
+		page.NextCursor = encodeCursor(end)
+	}
+	page.Alerts = alerts[offset:end]
+	p.log().DebugContext(ctx, "alert page", "matched", len(matched), "total", page.Total,
+		"offset", offset, "returned", len(page.Alerts))
+	return page, nil
+}
+
+// metadataOptions returns the QueryOptions carried in AlertQuery.Metadata
+// under their JSON names, so that callers such as OpsOrch Core, which only
+// send an AlertQuery, can sort and page. Metadata["search"] is left to
+// querySearch.
+func metadataOptions(query schema.AlertQuery) (QueryOptions, error) {
+	var opts QueryOptions
+	if len(query.Metadata) == 0 {
+		return opts, nil
+	}
+	data, err := json.Marshal(query.Metadata)
+	if err != nil {
+		return opts, fmt.Errorf("invalid query metadata: %w", err)
+	}
+	if err := json.Unmarshal(data, &opts); err != nil {
+		return opts, fmt.Errorf("invalid query metadata: %w", err)
+	}
+	opts.Search = ""
+	return opts, nil
+}
+
+// withDefaults fills the options left unset in o from defaults.
+func (o QueryOptions) withDefaults(defaults QueryOptions) QueryOptions {
+	if o.SortBy == "" {
+		o.SortBy = defaults.SortBy
+	}
+	if o.Order == "" {
+		o.Order = defaults.Order
+	}
+	if o.Offset == 0 {
+		o.Offset = defaults.Offset
+	}
+	if o.Cursor == "" {
+		o.Cursor = defaults.Cursor
+	}
+	if o.Start.IsZero() {
+		o.Start = defaults.Start
+	}
+	if o.End.IsZero() {
+		o.End = defaults.End
+	}
+	if o.TimeField == "" {
+		o.TimeField = defaults.TimeField
+	}
+	if o.Search == "" {
+		o.Search = defaults.Search
+	}
+	return o
+}
+
+// querySearch returns the free-text search carried by an AlertQuery: its
+// Query field, or Metadata["search"] when Query is empty.
+func querySearch(query schema.AlertQuery) string {
+	if query.Query != "" {
+		return query.Query
+	}
+	search, _ := query.Metadata["search"].(string)
+	return search
+}
+
+// validate checks the options and returns the starting offset.
+func (o QueryOptions) validate() (int, error) {
+	switch o.SortBy {
+	case "", SortBySeverity, SortByStartsAt, SortByUpdatedAt:
+	default:
+		return 0, fmt.Errorf("unsupported sortBy: %s", o.SortBy)
+	}
+	switch o.Order {
+	case "", OrderAsc, OrderDesc:
+	default:
+		return 0, fmt.Errorf("unsupported order: %s", o.Order)
+	}
+	switch o.TimeField {
+	case "", SortByStartsAt, SortByUpdatedAt:
+	default:
+		return 0, fmt.Errorf("unsupported timeField: %s", o.TimeField)
+	}
+	if !o.Start.IsZero() && !o.End.IsZero() && o.End.Before(o.Start) {
+		return 0, fmt.Errorf("invalid time window: end is before start")
+	}
+
+	if o.Cursor != "" {
+		return decodeCursor(o.Cursor)
+	}
+	if o.Offset < 0 {
+		return 0, fmt.Errorf("invalid offset: %d", o.Offset)
+	}
+	return o.Offset, nil
+}
+
+// inWindow reports whether the alert's TimeField falls within Start and End.
+func (o QueryOptions) inWindow(alert schema.Alert) bool {
+	t := alert.CreatedAt
+	if o.TimeField == SortByUpdatedAt {
+		t = alert.UpdatedAt
+	}
+	if !o.Start.IsZero() && t.Before(o.Start) {
+		return false
+	}
+	if !o.End.IsZero() && t.After(o.End) {
+		return false
+	}
+	return true
+}
+
+// sortAlerts sorts alerts in place, breaking ties by ID so the order is stable
+// across requests.
+func sortAlerts(alerts []schema.Alert, sortBy, order string) {
+	desc := order != OrderAsc
+	sort.SliceStable(alerts, func(i, j int) bool {
+		a, b := alerts[i], alerts[j]
+		var cmp int
+		switch sortBy {
+		case SortBySeverity:
+			cmp = compareSeverity(a.Severity, b.Severity)
+		case SortByUpdatedAt:
+			cmp = a.UpdatedAt.Compare(b.UpdatedAt)
+		default:
+			cmp = a.CreatedAt.Compare(b.CreatedAt)
+		}
+		if cmp != 0 {
+			return (cmp < 0) != desc
+		}
+		return a.ID < b.ID
+	})
+}
+
+func compareSeverity(a, b string) int {
+	ra, rb := severityRank[strings.ToLower(a)], severityRank[strings.ToLower(b)]
+	switch {
+	case ra < rb:
+		return -1
+	case ra > rb:
+		return 1
+	default:
+		// Unknown severities share a rank; order them by name for stability.
+		return strings.Compare(a, b)
+	}
+}
+
+func encodeCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(offset)))
+}
+
+func decodeCursor(cursor string) (int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, fmt.Errorf("invalid cursor: %w", err)
+	}
+	offset, err := strconv.Atoi(string(data))
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf("invalid cursor: %q", cursor)
+	}
+	return offset, nil
+}
@@ -0,0 +1,169 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-prometheus-adapter/apierr"
+)
+
+func newPageTestProvider(t *testing.T) *PrometheusAlertProvider {
+	t.Helper()
+	alert := func(fingerprint, severity, startsAt, updatedAt string) map[string]any {
+		return map[string]any{
+			"fingerprint": fingerprint,
+			"status":      map[string]any{"state": "active"},
+			"labels":      map[string]string{"alertname": "Alert-" + fingerprint, "severity": severity},
+			"annotations": map[string]string{},
+			"startsAt":    startsAt,
+			"updatedAt":   updatedAt,
+		}
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode([]map[string]any{
+			alert("a", "warning", "2025-12-03T10:00:00Z", "2025-12-03T10:30:00Z"),
+			alert("b", "critical", "2025-12-03T10:10:00Z", "2025-12-03T10:10:00Z"),
+			alert("c", "info", "2025-12-03T10:20:00Z", "2025-12-03T10:20:00Z"),
+			alert("d", "critical", "2025-12-03T10:20:00Z", "2025-12-03T10:25:00Z"),
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	prov, err := NewPrometheusAlertProvider(map[string]any{"alertmanagerURL": server.URL})
+	if err != nil {
+		t.Fatalf("NewPrometheusAlertProvider() error = %v", err)
+	}
+	return prov.(*PrometheusAlertProvider)
+}
+
+func alertIDs(alerts []schema.Alert) []string {
+	ids := make([]string, 0, len(alerts))
+	for _, alert := range alerts {
+		ids = append(ids, alert.ID)
+	}
+	return ids
+}
+
+func TestQueryPageSorting(t *testing.T) {
+	p := newPageTestProvider(t)
+
+	tests := []struct {
+		name string
+		opts QueryOptions
+		want []string
+	}{
+		{name: "default newest first", want: []string{"c", "d", "b", "a"}},
+		{name: "severity", opts: QueryOptions{SortBy: SortBySeverity}, want: []string{"b", "d", "a", "c"}},
+		{name: "updatedAt ascending", opts: QueryOptions{SortBy: SortByUpdatedAt, Order: OrderAsc}, want: []string{"b", "c", "d", "a"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := p.QueryPage(context.Background(), schema.AlertQuery{}, tt.opts)
+			if err != nil {
+				t.Fatalf("QueryPage() error = %v", err)
+			}
+			if got := alertIDs(page.Alerts); len(got) != len(tt.want) || !equalStrings(got, tt.want) {
+				t.Errorf("order = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueryPageCursor(t *testing.T) {
+	p := newPageTestProvider(t)
+	query := schema.AlertQuery{Limit: 3}
+
+	first, err := p.QueryPage(context.Background(), query, QueryOptions{})
+	if err != nil {
+		t.Fatalf("QueryPage() error = %v", err)
+	}
+	if first.Total != 4 || len(first.Alerts) != 3 || first.NextCursor == "" {
+		t.Fatalf("first page = %v total=%d cursor=%q", alertIDs(first.Alerts), first.Total, first.NextCursor)
+	}
+
+	second, err := p.QueryPage(context.Background(), query, QueryOptions{Cursor: first.NextCursor})
+	if err != nil {
+		t.Fatalf("QueryPage() error = %v", err)
+	}
+	if got := alertIDs(second.Alerts); !equalStrings(got, []string{"a"}) || second.NextCursor != "" {
+		t.Errorf("second page = %v cursor=%q, want [a] and no cursor", got, second.NextCursor)
+	}
+
+	if _, err := p.QueryPage(context.Background(), query, QueryOptions{Cursor: "not-a-cursor"}); err == nil {
+		t.Error("expected error for invalid cursor")
+	}
+}
+
+func TestQueryPageTimeWindow(t *testing.T) {
+	p := newPageTestProvider(t)
+
+	page, err := p.QueryPage(context.Background(), schema.AlertQuery{}, QueryOptions{
+		Start:     time.Date(2025, 12, 3, 10, 20, 0, 0, time.UTC),
+		TimeField: SortByUpdatedAt,
+	})
+	if err != nil {
+		t.Fatalf("QueryPage() error = %v", err)
+	}
+	if got := alertIDs(page.Alerts); !equalStrings(got, []string{"c", "d", "a"}) || page.Total != 3 {
+		t.Errorf("alerts = %v total=%d, want [c d a]", got, page.Total)
+	}
+
+	page, err = p.QueryPage(context.Background(), schema.AlertQuery{}, QueryOptions{
+		End: time.Date(2025, 12, 3, 10, 10, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("QueryPage() error = %v", err)
+	}
+	if got := alertIDs(page.Alerts); !equalStrings(got, []string{"b", "a"}) {
+		t.Errorf("alerts = %v, want [b a]", got)
+	}
+
+	if _, err := p.QueryPage(context.Background(), schema.AlertQuery{}, QueryOptions{SortBy: "title"}); err == nil {
+		t.Error("expected error for unsupported sortBy")
+	}
+}
+
+func TestQueryMetadataOptions(t *testing.T) {
+	p := newPageTestProvider(t)
+
+	query := schema.AlertQuery{Limit: 2, Metadata: map[string]any{"order": "asc", "offset": float64(1), "end": "2025-12-03T10:10:00Z"}}
+	alerts, err := p.Query(context.Background(), query)
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if got := alertIDs(alerts); !equalStrings(got, []string{"b"}) {
+		t.Errorf("alerts = %v, want [b]", got)
+	}
+
+	// Options passed to QueryPage take precedence over the metadata.
+	page, err := p.QueryPage(context.Background(), query, QueryOptions{Order: OrderDesc})
+	if err != nil {
+		t.Fatalf("QueryPage() error = %v", err)
+	}
+	if got := alertIDs(page.Alerts); !equalStrings(got, []string{"a"}) || page.Total != 2 {
+		t.Errorf("alerts = %v total=%d, want [a]", got, page.Total)
+	}
+
+	_, err = p.Query(context.Background(), schema.AlertQuery{Metadata: map[string]any{"offset": "ten"}})
+	if apierr.CodeOf(err) != apierr.InvalidArgument {
+		t.Errorf("Query() with invalid offset error = %v, want invalid_argument", err)
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,307 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/opsorch/opsorch-prometheus-adapter/apierr"
+)
+
+// Peer modes supported by the alertmanagerMode config field.
+const (
+	// PeerModeFailover sends each request to one healthy peer, failing over to the next on errors.
+	PeerModeFailover = "failover"
+	// PeerModeMerge queries every peer and merges alerts, deduplicated by fingerprint.
+	PeerModeMerge = "merge"
+)
+
+// peerHealthTTL is how long a failed peer is tried after the others.
+const peerHealthTTL = 30 * time.Second
+
+// peerSet tracks the Alertmanager cluster peers and their health.
+type peerSet struct {
+	urls []string
+	mode string
+
+	mu     sync.Mutex
+	health map[string]peerHealth
+	now    func() time.Time
+}
+
+type peerHealth struct {
+	healthy bool
+	checked time.Time
+}
+
+// newPeerSetFromConfig reads alertmanagerURL and the optional alertmanagerURLs
+// list of cluster peers. At least one URL is required.
+func newPeerSetFromConfig(config map[string]any) (*peerSet, error) {
+	var urls []string
+	seen := map[string]bool{}
+	add := func(u string) {
+		u = strings.TrimSuffix(u, "/")
+		if u != "" && !seen[u] {
+			seen[u] = true
+			urls = append(urls, u)
+		}
+	}
+
+	if u, ok := config["alertmanagerURL"].(string); ok {
+		add(u)
+	}
+	peerURLs, err := stringListConfig(config, "alertmanagerURLs")
+	if err != nil {
+		return nil, err
+	}
+	for _, u := range peerURLs {
+		add(u)
+	}
+
+	if len(urls) == 0 {
+		return nil, fmt.Errorf("missing required config field: alertmanagerURL")
+	}
+
+	mode, _ := config["alertmanagerMode"].(string)
+	switch mode {
+	case "":
+		mode = PeerModeFailover
+	case PeerModeFailover, PeerModeMerge:
+	default:
+		return nil, fmt.Errorf("unsupported alertmanagerMode: %s", mode)
+	}
+
+	return &peerSet{
+		urls:   urls,
+		mode:   mode,
+		health: map[string]peerHealth{},
+		now:    time.Now,
+	}, nil
+}
+
+// apiError is a non-200 response from the Alertmanager API.
+type apiError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("alertmanager API error: %d %s", e.StatusCode, e.Body)
+}
+
+// newAPIError reads a non-200 response into an apiError, classified by its
+// status code.
+func newAPIError(resp *http.Response) error {
+	bodyBytes, _ := io.ReadAll(resp.Body)
+	return apierr.Wrap(apierr.FromStatus(resp.StatusCode),
+		&apiError{StatusCode: resp.StatusCode, Body: string(bodyBytes)},
+		map[string]any{"status": resp.StatusCode})
+}
+
+// peerURLs returns the configured peers, or the single base URL for providers
+// built without a peer set.
+func (p *PrometheusAlertProvider) peerURLs() []string {
+	if p.peers == nil {
+		return []string{p.baseURL}
+	}
+	return p.peers.urls
+}
+
+// candidates orders peers for a request: peers in configured order, except
+// that peers which failed within the last peerHealthTTL go last. No health
+// check runs on the request path; peers are marked as requests succeed or fail.
+func (p *PrometheusAlertProvider) candidates() []string {
+	urls := p.peerURLs()
+	if len(urls) == 1 {
+		return urls
+	}
+
+	p.peers.mu.Lock()
+	defer p.peers.mu.Unlock()
+	now := p.peers.now()
+	ordered := make([]string, 0, len(urls))
+	var failed []string
+	for _, base := range urls {
+		if health, ok := p.peers.health[base]; ok && !health.healthy && now.Sub(health.checked) < peerHealthTTL {
+			failed = append(failed, base)
+		} else {
+			ordered = append(ordered, base)
+		}
+	}
+	return append(ordered, failed...)
+}
+
+func (p *PrometheusAlertProvider) markPeer(base string, healthy bool) {
+	if p.peers == nil {
+		return
+	}
+	p.peers.mu.Lock()
+	defer p.peers.mu.Unlock()
+	p.peers.health[base] = peerHealth{healthy: healthy, checked: p.peers.now()}
+}
+
+// getJSON issues a GET against the Alertmanager API and decodes the JSON body
+// into out, failing over to the next peer when a peer is unreachable or
+// returns a server error.
+func (p *PrometheusAlertProvider) getJSON(ctx context.Context, path string, params url.Values, out any) error {
+	var lastErr error
+	for _, base := range p.candidates() {
+		err := p.getJSONFrom(ctx, base, path, params, out)
+		if err == nil {
+			p.markPeer(base, true)
+			return nil
+		}
+		if !isPeerFailure(ctx, err) {
+			return err
+		}
+		p.log().WarnContext(ctx, "alertmanager peer failed", "peer", base, "path", path, "error", err)
+		p.markPeer(base, false)
+		lastErr = err
+	}
+	return lastErr
+}
+
+// fetchAlerts lists alerts from the cluster. In merge mode every peer is
+// queried and alerts are deduplicated by fingerprint, keeping the most
+// recently updated copy; unreachable peers are skipped as long as one answers.
+func (p *PrometheusAlertProvider) fetchAlerts(ctx context.Context, params url.Values) ([]alertmanagerAlert, error) {
+	urls := p.peerURLs()
+	if p.peers == nil || p.peers.mode != PeerModeMerge || len(urls) == 1 {
+		var amAlerts []alertmanagerAlert
+		if err := p.getJSON(ctx, "/api/v2/alerts", params, &amAlerts); err != nil {
+			return nil, err
+		}
+		return amAlerts, nil
+	}
+
+	type peerResult struct {
+		alerts []alertmanagerAlert
+		err    error
+	}
+	results := make([]peerResult, len(urls))
+	var wg sync.WaitGroup
+	for i, base := range urls {
+		wg.Add(1)
+		go func(i int, base string) {
+			defer wg.Done()
+			var amAlerts []alertmanagerAlert
+			err := p.getJSONFrom(ctx, base, "/api/v2/alerts", params, &amAlerts)
+			p.markPeer(base, err == nil || !isPeerFailure(ctx, err))
+			results[i] = peerResult{alerts: amAlerts, err: err}
+		}(i, base)
+	}
+	wg.Wait()
+
+	var (
+		merged  []alertmanagerAlert
+		index   = map[string]int{}
+		lastErr error
+		ok      bool
+	)
+	for i, result := range results {
+		if result.err != nil {
+			p.log().WarnContext(ctx, "alertmanager peer failed", "peer", urls[i], "path", "/api/v2/alerts", "error", result.err)
+			lastErr = result.err
+			continue
+		}
+		ok = true
+		for _, amAlert := range result.alerts {
+			i, seen := index[amAlert.Fingerprint]
+			if !seen {
+				index[amAlert.Fingerprint] = len(merged)
+				merged = append(merged, amAlert)
+				continue
+			}
+			if updatedAfter(amAlert.UpdatedAt, merged[i].UpdatedAt) {
+				merged[i] = amAlert
+			}
+		}
+	}
+	if !ok {
+		return nil, lastErr
+	}
+	return merged, nil
+}
+
+// getJSONFrom issues a GET against a single Alertmanager peer.
+func (p *PrometheusAlertProvider) getJSONFrom(ctx context.Context, base, path string, params url.Values, out any) error {
+	apiURL := base + path
+	if len(params) > 0 {
+		apiURL += "?" + params.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+
+	resp, err := p.do(req)
+	if err != nil {
+		return fmt.Errorf("execute request: %w", apierr.Classify(err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return newAPIError(resp)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode response: %w", apierr.Wrap(apierr.Unavailable, err, nil))
+	}
+	return nil
+}
+
+// do sends req and logs the upstream status and latency at debug level.
+func (p *PrometheusAlertProvider) do(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := p.client.Do(req)
+	if err != nil {
+		p.log().DebugContext(req.Context(), "alertmanager request failed", "method", req.Method,
+			"url", req.URL.String(), "latency", time.Since(start), "error", err)
+		return nil, err
+	}
+	p.log().DebugContext(req.Context(), "alertmanager request", "method", req.Method,
+		"url", req.URL.String(), "status", resp.StatusCode, "latency", time.Since(start))
+	return resp, nil
+}
+
+// log returns the provider's logger, falling back to slog.Default() for
+// providers built without NewPrometheusAlertProvider.
+func (p *PrometheusAlertProvider) log() *slog.Logger {
+	if p.logger == nil {
+		return slog.Default()
+	}
+	return p.logger
+}
+
+// isPeerFailure reports whether an error means the peer itself is unavailable,
+// as opposed to a rejected request that would fail on every peer.
+func isPeerFailure(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var apiErr *apiError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode >= 500
+	}
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
+
+// updatedAfter compares two RFC3339 updatedAt timestamps.
+func updatedAfter(a, b string) bool {
+	ta, errA := time.Parse(time.RFC3339, a)
+	tb, errB := time.Parse(time.RFC3339, b)
+	if errA != nil || errB != nil {
+		return a > b
+	}
+	return ta.After(tb)
+}
@@ -0,0 +1,170 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/opsorch/opsorch-core/schema"
+)
+
+func newPeerServer(t *testing.T, status int, alerts []map[string]any) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v2/status":
+			w.WriteHeader(status)
+			json.NewEncoder(w).Encode(map[string]any{"cluster": map[string]string{"status": "ready"}})
+		case "/api/v2/alerts":
+			w.WriteHeader(status)
+			json.NewEncoder(w).Encode(alerts)
+		default:
+			t.Errorf("unexpected request path %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func peerAlert(fingerprint, updatedAt string) map[string]any {
+	return map[string]any{
+		"fingerprint": fingerprint,
+		"status":      map[string]any{"state": "active"},
+		"labels":      map[string]string{"alertname": "Alert-" + fingerprint},
+		"annotations": map[string]string{},
+		"startsAt":    "2025-12-03T10:00:00Z",
+		"updatedAt":   updatedAt,
+	}
+}
+
+func TestFailoverToHealthyPeer(t *testing.T) {
+	down := newPeerServer(t, http.StatusServiceUnavailable, nil)
+	defer down.Close()
+	up := newPeerServer(t, http.StatusOK, []map[string]any{peerAlert("abc123", "2025-12-03T10:05:00Z")})
+	defer up.Close()
+
+	prov, err := NewPrometheusAlertProvider(map[string]any{
+		"alertmanagerURLs": []any{down.URL, up.URL},
+	})
+	if err != nil {
+		t.Fatalf("NewPrometheusAlertProvider() error = %v", err)
+	}
+
+	alerts, err := prov.Query(context.Background(), schema.AlertQuery{})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(alerts) != 1 || alerts[0].ID != "abc123" {
+		t.Fatalf("alerts = %+v, want abc123 from healthy peer", alerts)
+	}
+
+	// A peer that passed its health check but fails the request is skipped too.
+	flaky := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v2/status" {
+			json.NewEncoder(w).Encode(map[string]any{})
+			return
+		}
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer flaky.Close()
+
+	prov, err = NewPrometheusAlertProvider(map[string]any{
+		"alertmanagerURL":  flaky.URL,
+		"alertmanagerURLs": up.URL,
+	})
+	if err != nil {
+		t.Fatalf("NewPrometheusAlertProvider() error = %v", err)
+	}
+	if _, err := prov.Get(context.Background(), "abc123"); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+}
+
+func TestFailedPeerIsTriedLast(t *testing.T) {
+	var downRequests atomic.Int32
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		downRequests.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer down.Close()
+	up := newPeerServer(t, http.StatusOK, []map[string]any{peerAlert("abc123", "2025-12-03T10:05:00Z")})
+	defer up.Close()
+
+	prov, err := NewPrometheusAlertProvider(map[string]any{
+		"alertmanagerURLs": []any{down.URL, up.URL},
+	})
+	if err != nil {
+		t.Fatalf("NewPrometheusAlertProvider() error = %v", err)
+	}
+
+	// The first query fails over from the down peer; later queries skip it
+	// without a health check on the request path.
+	for i := 0; i < 3; i++ {
+		if _, err := prov.Query(context.Background(), schema.AlertQuery{}); err != nil {
+			t.Fatalf("Query() error = %v", err)
+		}
+	}
+	if got := downRequests.Load(); got != 1 {
+		t.Errorf("down peer got %d requests, want 1", got)
+	}
+}
+
+func TestMergePeers(t *testing.T) {
+	first := newPeerServer(t, http.StatusOK, []map[string]any{
+		peerAlert("abc123", "2025-12-03T10:05:00Z"),
+		peerAlert("def456", "2025-12-03T10:05:00Z"),
+	})
+	defer first.Close()
+	second := newPeerServer(t, http.StatusOK, []map[string]any{
+		peerAlert("abc123", "2025-12-03T10:07:00Z"),
+		peerAlert("ghi789", "2025-12-03T10:05:00Z"),
+	})
+	defer second.Close()
+	down := newPeerServer(t, http.StatusInternalServerError, nil)
+	defer down.Close()
+
+	prov, err := NewPrometheusAlertProvider(map[string]any{
+		"alertmanagerURLs": []any{first.URL, second.URL, down.URL},
+		"alertmanagerMode": PeerModeMerge,
+	})
+	if err != nil {
+		t.Fatalf("NewPrometheusAlertProvider() error = %v", err)
+	}
+
+	alerts, err := prov.Query(context.Background(), schema.AlertQuery{})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(alerts) != 3 {
+		t.Fatalf("expected 3 deduplicated alerts, got %d", len(alerts))
+	}
+	for _, alert := range alerts {
+		if alert.ID == "abc123" && alert.UpdatedAt.Minute() != 7 {
+			t.Errorf("expected most recent copy of abc123, got updatedAt %v", alert.UpdatedAt)
+		}
+	}
+}
+
+func TestNewPeerSetFromConfig(t *testing.T) {
+	peers, err := newPeerSetFromConfig(map[string]any{
+		"alertmanagerURL":  "http://am-0:9093/",
+		"alertmanagerURLs": "http://am-0:9093, http://am-1:9093",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(peers.urls) != 2 || peers.urls[1] != "http://am-1:9093" {
+		t.Errorf("urls = %v, want deduplicated am-0 and am-1", peers.urls)
+	}
+	if peers.mode != PeerModeFailover {
+		t.Errorf("mode = %v, want failover", peers.mode)
+	}
+
+	if _, err := newPeerSetFromConfig(map[string]any{"alertmanagerURLs": []any{}}); err == nil {
+		t.Error("expected error without any URL")
+	}
+	if _, err := newPeerSetFromConfig(map[string]any{"alertmanagerURL": "http://am-0:9093", "alertmanagerMode": "random"}); err == nil {
+		t.Error("expected error for unknown alertmanagerMode")
+	}
+}
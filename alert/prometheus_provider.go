@@ -2,36 +2,135 @@ package alert
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	corealert "github.com/opsorch/opsorch-core/alert"
 	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-prometheus-adapter/apierr"
+	"github.com/opsorch/opsorch-prometheus-adapter/httptransport"
+	"github.com/opsorch/opsorch-prometheus-adapter/logging"
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
 )
 
 // ProviderName is the registry key for the Prometheus alert adapter.
 const ProviderName = "prometheus"
 
+// Alert link modes supported by the alertLinkMode config field.
+const (
+	// LinkModeGenerator links to the alert's generatorURL, the Prometheus graph of the rule expression.
+	LinkModeGenerator = "generator"
+	// LinkModeAlertmanager links to the Alertmanager UI filtered by the alert's labels.
+	LinkModeAlertmanager = "alertmanager"
+)
+
 // PrometheusAlertProvider implements alert.Provider for Prometheus Alertmanager.
 type PrometheusAlertProvider struct {
-	baseURL string
-	client  *http.Client
+	baseURL       string
+	peers         *peerSet
+	severity      severityMapping
+	fields        fieldMapping
+	allowWrite    bool
+	externalURL   string
+	linkMode      string
+	client        *http.Client
+	tracker       *stateTracker
+	rulesAPI      v1.API
+	prometheusURL string
+	series        *seriesEnricher
+	flaps         *flapDetector
+	logger        *slog.Logger
 }
 
-// NewPrometheusAlertProvider creates a new Prometheus alert provider.
+// NewPrometheusAlertProvider creates a new Prometheus alert provider with its
+// own transport, built from the auth and TLS fields in config.
 func NewPrometheusAlertProvider(config map[string]any) (corealert.Provider, error) {
-	alertmanagerURL, ok := config["alertmanagerURL"].(string)
-	if !ok || alertmanagerURL == "" {
-		return nil, fmt.Errorf("missing required config field: alertmanagerURL")
+	transport, err := httptransport.New(config)
+	if err != nil {
+		return nil, err
+	}
+	return NewPrometheusAlertProviderWithTransport(config, transport)
+}
+
+// NewPrometheusAlertProviderWithTransport creates a Prometheus alert provider
+// that sends its Alertmanager and Prometheus requests through a shared
+// transport, ignoring any auth and TLS fields in config. A nil transport is
+// replaced by one without auth or TLS settings.
+func NewPrometheusAlertProviderWithTransport(config map[string]any, transport *httptransport.Transport) (*PrometheusAlertProvider, error) {
+	if transport == nil {
+		transport, _ = httptransport.New(nil)
+	}
+	peers, err := newPeerSetFromConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	severity, err := newSeverityMappingFromConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	fields, err := newFieldMappingFromConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	externalURL, _ := config["externalURL"].(string)
+	allowWrite, _ := config["allowWrite"].(bool)
+
+	linkMode, _ := config["alertLinkMode"].(string)
+	switch linkMode {
+	case "", LinkModeGenerator, LinkModeAlertmanager:
+	default:
+		return nil, fmt.Errorf("unsupported alertLinkMode: %s", linkMode)
+	}
+
+	tracker, err := newStateTrackerFromConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	rulesAPI, prometheusURL, err := newRulesAPI(config, transport)
+	if err != nil {
+		return nil, err
+	}
+
+	series, err := newSeriesEnricherFromConfig(config, prometheusURL, transport)
+	if err != nil {
+		return nil, err
+	}
+
+	flaps, err := newFlapDetectorFromConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	logger, err := logging.FromConfig(config)
+	if err != nil {
+		return nil, err
 	}
 
 	return &PrometheusAlertProvider{
-		baseURL: alertmanagerURL,
-		client:  &http.Client{Timeout: 30 * time.Second},
+		baseURL:       peers.urls[0],
+		peers:         peers,
+		severity:      severity,
+		fields:        fields,
+		allowWrite:    allowWrite,
+		externalURL:   externalURL,
+		linkMode:      linkMode,
+		client:        &http.Client{Timeout: 30 * time.Second, Transport: transport},
+		tracker:       tracker,
+		rulesAPI:      rulesAPI,
+		prometheusURL: prometheusURL,
+		logger:        logger,
+		series:        series,
+		flaps:         flaps,
 	}, nil
 }
 
@@ -39,131 +138,253 @@ func init() {
 	_ = corealert.RegisterProvider(ProviderName, NewPrometheusAlertProvider)
 }
 
-// Query fetches alerts from Prometheus Alertmanager.
+// Query fetches alerts from Prometheus Alertmanager, sorted newest first.
 func (p *PrometheusAlertProvider) Query(ctx context.Context, query schema.AlertQuery) ([]schema.Alert, error) {
-	params := url.Values{}
+	alerts, _, err := p.QueryWithWarnings(ctx, query)
+	return alerts, err
+}
 
-	// Add filters
-	if len(query.Statuses) > 0 {
-		for _, status := range query.Statuses {
-			// Prometheus Alertmanager states: active, suppressed, unprocessed
-			params.Add("filter", fmt.Sprintf("state=\"%s\"", mapStatusToAlertmanager(status)))
-		}
+// QueryWithWarnings is Query, also returning warnings about sources that
+// could not be read, such as a failed Prometheus rules query. The alerts are
+// then incomplete.
+func (p *PrometheusAlertProvider) QueryWithWarnings(ctx context.Context, query schema.AlertQuery) ([]schema.Alert, []string, error) {
+	page, err := p.QueryPage(ctx, query, QueryOptions{})
+	if err != nil {
+		return nil, nil, err
 	}
+	return page.Alerts, page.Warnings, nil
+}
 
-	if len(query.Severities) > 0 {
-		for _, severity := range query.Severities {
-			params.Add("filter", fmt.Sprintf("severity=\"%s\"", severity))
-		}
+// matchingAlerts returns every alert matching the query filters, ignoring
+// Limit, and any warnings from merging in rule alerts.
+func (p *PrometheusAlertProvider) matchingAlerts(ctx context.Context, query schema.AlertQuery) ([]schema.Alert, []string, error) {
+	if p.tracker != nil || p.rulesAPI != nil {
+		return p.queryMerged(ctx, query)
 	}
 
-	// Add scope filters
-	if query.Scope.Service != "" {
-		params.Add("filter", fmt.Sprintf("service=\"%s\"", query.Scope.Service))
-	}
-	if query.Scope.Team != "" {
-		params.Add("filter", fmt.Sprintf("team=\"%s\"", query.Scope.Team))
+	amAlerts, err := p.fetchAlerts(ctx, p.buildAlertFilters(query))
+	if err != nil {
+		return nil, nil, err
 	}
-	if query.Scope.Environment != "" {
-		params.Add("filter", fmt.Sprintf("env=\"%s\"", query.Scope.Environment))
+
+	alerts := make([]schema.Alert, 0, len(amAlerts))
+	for _, amAlert := range amAlerts {
+		alert := p.convertAlertmanagerAlert(amAlert)
+		if matchesStatus(alert, query.Statuses) && matchesSeverity(alert, query.Severities) {
+			alerts = append(alerts, alert)
+		}
 	}
+	p.observeFlapping(alerts)
 
-	apiURL := fmt.Sprintf("%s/api/v2/alerts?%s", p.baseURL, params.Encode())
+	return alerts, nil, nil
+}
 
-	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+// Get fetches a single alert by fingerprint from Prometheus Alertmanager.
+func (p *PrometheusAlertProvider) Get(ctx context.Context, id string) (schema.Alert, error) {
+	alert, _, err := p.GetWithWarnings(ctx, id)
+	return alert, err
+}
+
+// GetWithWarnings is Get, also returning warnings about sources that could
+// not be read, such as a failed Prometheus rules query. The rule fields of the
+// alert are then missing.
+func (p *PrometheusAlertProvider) GetWithWarnings(ctx context.Context, id string) (schema.Alert, []string, error) {
+	alert, warnings, err := p.find(ctx, id)
 	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
+		return schema.Alert{}, nil, err
 	}
+	alerts := []schema.Alert{alert}
+	p.observeFlapping(alerts)
+	return p.enrich(ctx, alerts[0]), warnings, nil
+}
 
-	resp, err := p.client.Do(req)
+// find looks up an alert by fingerprint among live, rule and tracked alerts.
+// When the rules query failed, an alert that is not found may be a pending
+// rule alert, so the error is unavailable rather than not_found.
+func (p *PrometheusAlertProvider) find(ctx context.Context, id string) (schema.Alert, []string, error) {
+	amAlerts, err := p.fetchAlerts(ctx, nil)
 	if err != nil {
-		return nil, fmt.Errorf("execute request: %w", err)
+		return schema.Alert{}, nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("alertmanager API error: %d %s", resp.StatusCode, string(bodyBytes))
+	alerts := make([]schema.Alert, 0, len(amAlerts))
+	for _, amAlert := range amAlerts {
+		alerts = append(alerts, p.convertAlertmanagerAlert(amAlert))
 	}
 
-	var amAlerts []alertmanagerAlert
-	if err := json.NewDecoder(resp.Body).Decode(&amAlerts); err != nil {
-		return nil, fmt.Errorf("decode response: %w", err)
+	ruleAlerts, warnings := p.ruleAlerts(ctx)
+	alerts = mergeRuleAlerts(alerts, ruleAlerts)
+
+	// Find alert by fingerprint (ID)
+	for _, alert := range alerts {
+		if alert.ID == id {
+			return alert, warnings, nil
+		}
 	}
 
-	alerts := make([]schema.Alert, 0, len(amAlerts))
-	for _, amAlert := range amAlerts {
-		alerts = append(alerts, convertAlertmanagerAlert(amAlert))
+	// Fall back to alerts that have already resolved
+	if p.tracker != nil {
+		alert, ok, err := p.tracker.lookup(id)
+		if err != nil {
+			return schema.Alert{}, nil, err
+		}
+		if ok {
+			return alert, warnings, nil
+		}
+	}
+
+	if len(warnings) > 0 {
+		return schema.Alert{}, nil, apierr.New(apierr.Unavailable, "alert not found: %s: %s", id, strings.Join(warnings, "; "))
 	}
+	return schema.Alert{}, nil, apierr.New(apierr.NotFound, "alert not found: %s", id)
+}
 
-	// Apply limit if specified
-	if query.Limit > 0 && query.Limit < len(alerts) {
-		alerts = alerts[:query.Limit]
+// Close releases the provider's idle connections. The plugin calls it when
+// evicting a cached provider.
+func (p *PrometheusAlertProvider) Close() error {
+	p.client.CloseIdleConnections()
+	if p.series != nil {
+		p.series.close()
 	}
+	return nil
+}
 
-	return alerts, nil
+// observeFlapping feeds alerts to the flapping detector, when enabled.
+func (p *PrometheusAlertProvider) observeFlapping(alerts []schema.Alert) {
+	if p.flaps != nil {
+		p.flaps.observe(alerts)
+	}
 }
 
-// Get fetches a single alert by fingerprint from Prometheus Alertmanager.
-func (p *PrometheusAlertProvider) Get(ctx context.Context, id string) (schema.Alert, error) {
-	apiURL := fmt.Sprintf("%s/api/v2/alerts", p.baseURL)
+// enrich attaches the alert's metric series when seriesEnrichment is enabled.
+func (p *PrometheusAlertProvider) enrich(ctx context.Context, alert schema.Alert) schema.Alert {
+	if p.series == nil {
+		return alert
+	}
+	// Copy before writing: the maps may be shared with tracked state.
+	alert.Fields = cloneMap(alert.Fields)
+	if alert.Fields == nil {
+		alert.Fields = map[string]any{}
+	}
+	alert.Metadata = cloneMap(alert.Metadata)
+	if alert.Metadata == nil {
+		alert.Metadata = map[string]any{}
+	}
+	p.series.enrich(ctx, &alert)
+	return alert
+}
 
-	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+// queryMerged fetches the full Alertmanager snapshot, lets the state tracker
+// detect resolved alerts, merges in Prometheus rule alerts and then applies
+// the query filters client-side.
+func (p *PrometheusAlertProvider) queryMerged(ctx context.Context, query schema.AlertQuery) ([]schema.Alert, []string, error) {
+	amAlerts, err := p.fetchAlerts(ctx, nil)
 	if err != nil {
-		return schema.Alert{}, fmt.Errorf("create request: %w", err)
+		return nil, nil, err
 	}
 
-	resp, err := p.client.Do(req)
-	if err != nil {
-		return schema.Alert{}, fmt.Errorf("execute request: %w", err)
+	merged := make([]schema.Alert, 0, len(amAlerts))
+	for _, amAlert := range amAlerts {
+		merged = append(merged, p.convertAlertmanagerAlert(amAlert))
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return schema.Alert{}, fmt.Errorf("alertmanager API error: %d %s", resp.StatusCode, string(bodyBytes))
+	if p.tracker != nil {
+		tracked, err := p.tracker.reconcile(merged)
+		if err != nil {
+			return nil, nil, err
+		}
+		merged = tracked
 	}
 
-	var amAlerts []alertmanagerAlert
-	if err := json.NewDecoder(resp.Body).Decode(&amAlerts); err != nil {
-		return schema.Alert{}, fmt.Errorf("decode response: %w", err)
+	ruleAlerts, warnings := p.ruleAlerts(ctx)
+	merged = mergeRuleAlerts(merged, ruleAlerts)
+	p.observeFlapping(merged)
+
+	alerts := make([]schema.Alert, 0, len(merged))
+	for _, alert := range merged {
+		if matchesQuery(alert, query) {
+			alerts = append(alerts, alert)
+		}
 	}
 
-	// Find alert by fingerprint (ID)
-	for _, amAlert := range amAlerts {
-		if amAlert.Fingerprint == id {
-			return convertAlertmanagerAlert(amAlert), nil
+	return alerts, warnings, nil
+}
+
+// buildAlertFilters translates an AlertQuery into Alertmanager query
+// parameters. Statuses select the active, silenced, inhibited and unprocessed
+// flags; Alertmanager only holds unresolved alerts, so a query for resolved
+// alerts alone clears every flag.
+func (p *PrometheusAlertProvider) buildAlertFilters(query schema.AlertQuery) url.Values {
+	params := url.Values{}
+
+	if len(query.Statuses) > 0 {
+		states := alertmanagerStates(query.Statuses)
+		for _, state := range []string{"active", "silenced", "inhibited", "unprocessed"} {
+			params.Set(state, strconv.FormatBool(states[state]))
 		}
 	}
 
-	return schema.Alert{}, fmt.Errorf("alert not found: %s", id)
+	// Severities are normalized, so match every raw value that maps to them
+	if filter, ok := p.severity.filter(query.Severities); ok {
+		params.Add("filter", filter)
+	}
+
+	// Add scope filters
+	if query.Scope.Service != "" {
+		params.Add("filter", fmt.Sprintf("service=\"%s\"", query.Scope.Service))
+	}
+	if query.Scope.Team != "" {
+		params.Add("filter", fmt.Sprintf("team=\"%s\"", query.Scope.Team))
+	}
+	if query.Scope.Environment != "" {
+		params.Add("filter", fmt.Sprintf("env=\"%s\"", query.Scope.Environment))
+	}
+
+	return params
 }
 
-// alertmanagerAlert represents an alert from Prometheus Alertmanager API.
+// alertmanagerAlert represents a gettableAlert from the Alertmanager v2 API.
 type alertmanagerAlert struct {
-	Fingerprint string `json:"fingerprint"`
-	Status      struct {
-		State string `json:"state"` // firing, suppressed, etc.
-	} `json:"status"`
-	Labels      map[string]string `json:"labels"`
-	Annotations map[string]string `json:"annotations"`
-	StartsAt    string            `json:"startsAt"`
-	EndsAt      string            `json:"endsAt"`
-	UpdatedAt   string            `json:"updatedAt"`
-}
-
-func convertAlertmanagerAlert(amAlert alertmanagerAlert) schema.Alert {
+	Fingerprint  string                  `json:"fingerprint"`
+	Status       alertmanagerAlertStatus `json:"status"`
+	Receivers    []alertmanagerReceiver  `json:"receivers"`
+	Labels       map[string]string       `json:"labels"`
+	Annotations  map[string]string       `json:"annotations"`
+	StartsAt     string                  `json:"startsAt"`
+	EndsAt       string                  `json:"endsAt"`
+	UpdatedAt    string                  `json:"updatedAt"`
+	GeneratorURL string                  `json:"generatorURL"`
+}
+
+// alertmanagerAlertStatus is the status block of a gettableAlert.
+type alertmanagerAlertStatus struct {
+	State       string   `json:"state"`       // active, suppressed, unprocessed
+	SilencedBy  []string `json:"silencedBy"`  // silence IDs
+	InhibitedBy []string `json:"inhibitedBy"` // fingerprints of inhibiting alerts
+}
+
+// alertmanagerReceiver is a receiver reference in the Alertmanager API.
+type alertmanagerReceiver struct {
+	Name string `json:"name"`
+}
+
+func (p *PrometheusAlertProvider) convertAlertmanagerAlert(amAlert alertmanagerAlert) schema.Alert {
+	receivers := make([]string, 0, len(amAlert.Receivers))
+	for _, receiver := range amAlert.Receivers {
+		receivers = append(receivers, receiver.Name)
+	}
+
 	alert := schema.Alert{
-		ID:          amAlert.Fingerprint,
-		Title:       amAlert.Labels["alertname"],
-		Description: amAlert.Annotations["description"],
-		Status:      mapAlertmanagerStateToStatus(amAlert.Status.State),
-		Severity:    amAlert.Labels["severity"],
-		Service:     amAlert.Labels["service"],
-		URL:         "/alerting/alerts#" + amAlert.Fingerprint,
+		ID:       amAlert.Fingerprint,
+		Status:   mapAlertmanagerStateToStatus(amAlert.Status.State),
+		Severity: p.severity.normalize(amAlert.Labels),
+		URL:      p.alertURL(amAlert),
 		Fields: map[string]any{
 			"labels":      amAlert.Labels,
 			"annotations": amAlert.Annotations,
+			"silencedBy":  nonNilStrings(amAlert.Status.SilencedBy),
+			"inhibitedBy": nonNilStrings(amAlert.Status.InhibitedBy),
+			"receivers":   receivers,
 		},
 		Metadata: map[string]any{
 			"source":      "prometheus",
@@ -171,6 +392,25 @@ func convertAlertmanagerAlert(amAlert alertmanagerAlert) schema.Alert {
 		},
 	}
 
+	p.fields.apply(&alert, amAlert.Labels, amAlert.Annotations)
+
+	if amAlert.GeneratorURL != "" {
+		alert.Fields["generatorURL"] = amAlert.GeneratorURL
+		alert.Metadata["generatorURL"] = amAlert.GeneratorURL
+	}
+
+	// Record why a suppressed alert is muted
+	var suppressedBy []string
+	if len(amAlert.Status.SilencedBy) > 0 {
+		suppressedBy = append(suppressedBy, "silence")
+	}
+	if len(amAlert.Status.InhibitedBy) > 0 {
+		suppressedBy = append(suppressedBy, "inhibition")
+	}
+	if len(suppressedBy) > 0 {
+		alert.Metadata["suppressedBy"] = suppressedBy
+	}
+
 	if startsAt, err := time.Parse(time.RFC3339, amAlert.StartsAt); err == nil {
 		alert.CreatedAt = startsAt
 	}
@@ -179,18 +419,65 @@ func convertAlertmanagerAlert(amAlert alertmanagerAlert) schema.Alert {
 		alert.UpdatedAt = updatedAt
 	}
 
+	if endsAt, err := time.Parse(time.RFC3339, amAlert.EndsAt); err == nil && !endsAt.IsZero() {
+		alert.Fields["endsAt"] = endsAt
+	}
+
 	return alert
 }
 
-func mapStatusToAlertmanager(status string) string {
-	switch status {
-	case "firing", "open", "active":
-		return "active"
-	case "resolved", "closed":
-		return "suppressed"
-	default:
-		return status
+// alertURL builds an absolute link for an alert. By default it is the alert's
+// generatorURL; alerts without one, or all alerts in alertmanager link mode,
+// link to the Alertmanager UI filtered by the alert's labels.
+func (p *PrometheusAlertProvider) alertURL(amAlert alertmanagerAlert) string {
+	if p.linkMode != LinkModeAlertmanager && amAlert.GeneratorURL != "" {
+		return amAlert.GeneratorURL
+	}
+
+	base := p.externalURL
+	if base == "" {
+		base = p.baseURL
+	}
+	base = strings.TrimSuffix(base, "/")
+
+	names := make([]string, 0, len(amAlert.Labels))
+	for name := range amAlert.Labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	matchers := make([]string, 0, len(names))
+	for _, name := range names {
+		matchers = append(matchers, fmt.Sprintf("%s=%q", name, amAlert.Labels[name]))
+	}
+	filter := "{" + strings.Join(matchers, ",") + "}"
+
+	return base + "/#/alerts?filter=" + url.QueryEscape(filter)
+}
+
+func nonNilStrings(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+// alertmanagerStates returns the Alertmanager state flags that cover the
+// given OpsOrch statuses.
+func alertmanagerStates(statuses []string) map[string]bool {
+	states := map[string]bool{}
+	for _, status := range statuses {
+		switch normalizeQueryStatus(status) {
+		case "firing":
+			states["active"] = true
+		case "suppressed":
+			states["silenced"] = true
+			states["inhibited"] = true
+		case "pending":
+			states["unprocessed"] = true
+		}
 	}
+	return states
 }
 
 func mapAlertmanagerStateToStatus(state string) string {
@@ -5,9 +5,12 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
+	"time"
 
 	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-prometheus-adapter/apierr"
 )
 
 func TestNewPrometheusAlertProvider(t *testing.T) {
@@ -18,6 +21,16 @@ func TestNewPrometheusAlertProvider(t *testing.T) {
 		}
 	})
 
+	t.Run("rejects unknown alertLinkMode", func(t *testing.T) {
+		_, err := NewPrometheusAlertProvider(map[string]any{
+			"alertmanagerURL": "http://localhost:9093",
+			"alertLinkMode":   "grafana",
+		})
+		if err == nil {
+			t.Fatal("expected error for unknown alertLinkMode")
+		}
+	})
+
 	t.Run("creates provider successfully", func(t *testing.T) {
 		prov, err := NewPrometheusAlertProvider(map[string]any{
 			"alertmanagerURL": "http://localhost:9093",
@@ -91,6 +104,52 @@ func TestQuery(t *testing.T) {
 	}
 }
 
+func TestQueryStatuses(t *testing.T) {
+	var params url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		params = r.URL.Query()
+		json.NewEncoder(w).Encode([]map[string]any{{
+			"fingerprint": "abc123",
+			"status":      map[string]string{"state": "active"},
+			"labels":      map[string]string{"alertname": "HighCPU"},
+			"startsAt":    "2025-12-03T10:00:00Z",
+		}})
+	}))
+	defer server.Close()
+
+	prov, err := NewPrometheusAlertProvider(map[string]any{"alertmanagerURL": server.URL})
+	if err != nil {
+		t.Fatalf("NewPrometheusAlertProvider() error = %v", err)
+	}
+
+	tests := []struct {
+		statuses []string
+		want     map[string]string
+		alerts   int
+	}{
+		{statuses: []string{"firing"}, want: map[string]string{"active": "true", "silenced": "false", "inhibited": "false", "unprocessed": "false"}, alerts: 1},
+		{statuses: []string{"suppressed", "pending"}, want: map[string]string{"active": "false", "silenced": "true", "inhibited": "true", "unprocessed": "true"}, alerts: 0},
+		{statuses: []string{"resolved"}, want: map[string]string{"active": "false", "silenced": "false", "inhibited": "false", "unprocessed": "false"}, alerts: 0},
+	}
+	for _, tt := range tests {
+		alerts, err := prov.Query(context.Background(), schema.AlertQuery{Statuses: tt.statuses})
+		if err != nil {
+			t.Fatalf("Query(%v) error = %v", tt.statuses, err)
+		}
+		if len(alerts) != tt.alerts {
+			t.Errorf("Query(%v) returned %d alerts, want %d", tt.statuses, len(alerts), tt.alerts)
+		}
+		if params["filter"] != nil {
+			t.Errorf("Query(%v) sent filter %v", tt.statuses, params["filter"])
+		}
+		for state, want := range tt.want {
+			if got := params.Get(state); got != want {
+				t.Errorf("Query(%v) %s = %q, want %q", tt.statuses, state, got, want)
+			}
+		}
+	}
+}
+
 func TestGet(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path == "/api/v2/alerts" && r.Method == "GET" {
@@ -168,4 +227,121 @@ func TestGetNotFound(t *testing.T) {
 	if err == nil {
 		t.Fatal("expected error when alert not found")
 	}
+	if apierr.CodeOf(err) != apierr.NotFound {
+		t.Errorf("error code = %q, want %q", apierr.CodeOf(err), apierr.NotFound)
+	}
+}
+
+func TestQueryErrorCodes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	prov := &PrometheusAlertProvider{baseURL: server.URL, client: &http.Client{}}
+	_, err := prov.Query(context.Background(), schema.AlertQuery{})
+	if apierr.CodeOf(err) != apierr.Unauthorized {
+		t.Errorf("error = %v, code = %q, want %q", err, apierr.CodeOf(err), apierr.Unauthorized)
+	}
+	if status := apierr.DetailsOf(err)["status"]; status != http.StatusUnauthorized {
+		t.Errorf("status detail = %v", status)
+	}
+
+	server.Close()
+	_, err = prov.Query(context.Background(), schema.AlertQuery{})
+	if apierr.CodeOf(err) != apierr.Unavailable {
+		t.Errorf("error = %v, code = %q, want %q", err, apierr.CodeOf(err), apierr.Unavailable)
+	}
+}
+
+func TestConvertAlertmanagerAlertSuppression(t *testing.T) {
+	var amAlert alertmanagerAlert
+	err := json.Unmarshal([]byte(`{
+		"fingerprint": "sup123",
+		"status": {
+			"state": "suppressed",
+			"silencedBy": ["silence-1"],
+			"inhibitedBy": ["abc123"]
+		},
+		"receivers": [{"name": "oncall"}, {"name": "slack"}],
+		"labels": {"alertname": "DiskFull"},
+		"annotations": {},
+		"startsAt": "2025-12-03T10:00:00Z",
+		"endsAt": "2025-12-03T11:00:00Z",
+		"updatedAt": "2025-12-03T10:05:00Z",
+		"generatorURL": "http://prometheus:9090/graph?g0.expr=up"
+	}`), &amAlert)
+	if err != nil {
+		t.Fatalf("decode alert: %v", err)
+	}
+
+	prov := &PrometheusAlertProvider{baseURL: "http://alertmanager:9093"}
+	alert := prov.convertAlertmanagerAlert(amAlert)
+	if alert.Status != "suppressed" {
+		t.Errorf("Status = %v, want suppressed", alert.Status)
+	}
+	if got := alert.Fields["silencedBy"].([]string); len(got) != 1 || got[0] != "silence-1" {
+		t.Errorf("silencedBy = %v, want [silence-1]", got)
+	}
+	if got := alert.Fields["inhibitedBy"].([]string); len(got) != 1 || got[0] != "abc123" {
+		t.Errorf("inhibitedBy = %v, want [abc123]", got)
+	}
+	if got := alert.Fields["receivers"].([]string); len(got) != 2 || got[0] != "oncall" {
+		t.Errorf("receivers = %v, want [oncall slack]", got)
+	}
+	if got, ok := alert.Fields["endsAt"].(time.Time); !ok || got.Hour() != 11 {
+		t.Errorf("endsAt = %v, want 11:00", alert.Fields["endsAt"])
+	}
+	if alert.Metadata["generatorURL"] != "http://prometheus:9090/graph?g0.expr=up" {
+		t.Errorf("generatorURL = %v", alert.Metadata["generatorURL"])
+	}
+	if got := alert.Metadata["suppressedBy"].([]string); len(got) != 2 {
+		t.Errorf("suppressedBy = %v, want [silence inhibition]", got)
+	}
+}
+
+func TestAlertURL(t *testing.T) {
+	amAlert := alertmanagerAlert{
+		Fingerprint:  "abc123",
+		Labels:       map[string]string{"alertname": "HighCPU", "instance": "web-1"},
+		GeneratorURL: "http://prometheus:9090/graph?g0.expr=up",
+	}
+	wantAlertmanager := "https://alerts.example.com/#/alerts?filter=" +
+		url.QueryEscape(`{alertname="HighCPU",instance="web-1"}`)
+
+	tests := []struct {
+		name     string
+		prov     *PrometheusAlertProvider
+		amAlert  alertmanagerAlert
+		expected string
+	}{
+		{
+			name:     "defaults to generatorURL",
+			prov:     &PrometheusAlertProvider{baseURL: "http://alertmanager:9093"},
+			amAlert:  amAlert,
+			expected: "http://prometheus:9090/graph?g0.expr=up",
+		},
+		{
+			name:     "alertmanager mode uses externalURL",
+			prov:     &PrometheusAlertProvider{baseURL: "http://alertmanager:9093", externalURL: "https://alerts.example.com/", linkMode: LinkModeAlertmanager},
+			amAlert:  amAlert,
+			expected: wantAlertmanager,
+		},
+		{
+			name: "falls back to alertmanager without generatorURL",
+			prov: &PrometheusAlertProvider{baseURL: "http://alertmanager:9093"},
+			amAlert: alertmanagerAlert{
+				Labels: map[string]string{"alertname": "HighCPU"},
+			},
+			expected: "http://alertmanager:9093/#/alerts?filter=" + url.QueryEscape(`{alertname="HighCPU"}`),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.prov.alertURL(tt.amAlert); got != tt.expected {
+				t.Errorf("alertURL() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
 }
@@ -0,0 +1,201 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-prometheus-adapter/apierr"
+)
+
+// errWritesDisabled is returned by Create and Resolve unless allowWrite is set.
+var errWritesDisabled = apierr.New(apierr.Unauthorized, "alert writes are disabled: set allowWrite to enable")
+
+// syntheticLabel marks alerts pushed by Create; Resolve only resolves those.
+const syntheticLabel = "opsorch_synthetic"
+
+// postableAlert is the Alertmanager API v2 request body for one pushed alert.
+type postableAlert struct {
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations,omitempty"`
+	StartsAt     string            `json:"startsAt,omitempty"`
+	EndsAt       string            `json:"endsAt,omitempty"`
+	GeneratorURL string            `json:"generatorURL,omitempty"`
+}
+
+// Create pushes a synthetic alert to Alertmanager. Labels and annotations are
+// taken from Fields["labels"] and Fields["annotations"]; Title, Service,
+// Severity and Description fill in alertname, service, the severity label and
+// the description annotation when those are not already set. CreatedAt and
+// Fields["endsAt"] set startsAt and endsAt, and URL becomes the generatorURL.
+// The alert is labelled opsorch_synthetic="true" so that Resolve can tell it
+// apart from alerts sent by Prometheus.
+func (p *PrometheusAlertProvider) Create(ctx context.Context, alert schema.Alert) (schema.Alert, error) {
+	if !p.allowWrite {
+		return schema.Alert{}, errWritesDisabled
+	}
+
+	labels := copyStrings(alertLabels(alert))
+	annotations := copyStrings(stringMapField(alert, "annotations"))
+	setDefault(labels, "alertname", alert.Title)
+	setDefault(labels, "service", alert.Service)
+	setDefault(labels, p.severity.sourceLabels()[0], alert.Severity)
+	setDefault(annotations, "description", alert.Description)
+	if labels["alertname"] == "" {
+		return schema.Alert{}, apierr.New(apierr.InvalidArgument, "missing required alert field: title")
+	}
+	labels[syntheticLabel] = "true"
+
+	now := time.Now().UTC()
+	startsAt := alert.CreatedAt
+	if startsAt.IsZero() {
+		startsAt = now
+	}
+	amAlert := alertmanagerAlert{
+		Fingerprint:  labelsFingerprint(labels),
+		Labels:       labels,
+		Annotations:  annotations,
+		StartsAt:     startsAt.UTC().Format(time.RFC3339),
+		UpdatedAt:    now.Format(time.RFC3339),
+		GeneratorURL: alert.URL,
+	}
+	amAlert.Status.State = "active"
+	switch endsAt := alert.Fields["endsAt"].(type) {
+	case time.Time:
+		if !endsAt.IsZero() {
+			amAlert.EndsAt = endsAt.UTC().Format(time.RFC3339)
+		}
+	case string:
+		// Plugin payloads carry endsAt as an RFC3339 string.
+		if _, err := time.Parse(time.RFC3339, endsAt); err != nil {
+			return schema.Alert{}, apierr.New(apierr.InvalidArgument, "invalid endsAt: %w", err)
+		}
+		amAlert.EndsAt = endsAt
+	}
+
+	if err := p.pushAlerts(ctx, amAlert); err != nil {
+		return schema.Alert{}, err
+	}
+	return p.convertAlertmanagerAlert(amAlert), nil
+}
+
+// Resolve re-posts an active synthetic alert with endsAt set to now, which
+// makes Alertmanager resolve it and send resolved notifications. Alerts that
+// were not pushed by Create are rejected, since Prometheus would fire them
+// again on its next evaluation.
+func (p *PrometheusAlertProvider) Resolve(ctx context.Context, id string) (schema.Alert, error) {
+	if !p.allowWrite {
+		return schema.Alert{}, errWritesDisabled
+	}
+
+	alert, _, err := p.find(ctx, id)
+	if err != nil {
+		return schema.Alert{}, err
+	}
+	if alertLabels(alert)[syntheticLabel] != "true" || alert.Metadata["origin"] == "rules" {
+		return schema.Alert{}, apierr.New(apierr.InvalidArgument, "alert %s is not a synthetic alert", id)
+	}
+	if alert.Status == "resolved" {
+		return alert, nil
+	}
+
+	now := time.Now().UTC()
+	amAlert := alertmanagerAlert{
+		Fingerprint: id,
+		Labels:      alertLabels(alert),
+		Annotations: stringMapField(alert, "annotations"),
+		EndsAt:      now.Format(time.RFC3339),
+		UpdatedAt:   now.Format(time.RFC3339),
+	}
+	amAlert.Status.State = "active"
+	if !alert.CreatedAt.IsZero() {
+		amAlert.StartsAt = alert.CreatedAt.UTC().Format(time.RFC3339)
+	}
+	if generatorURL, ok := alert.Fields["generatorURL"].(string); ok {
+		amAlert.GeneratorURL = generatorURL
+	}
+
+	if err := p.pushAlerts(ctx, amAlert); err != nil {
+		return schema.Alert{}, err
+	}
+
+	resolved := p.convertAlertmanagerAlert(amAlert)
+	resolved.Status = "resolved"
+	if p.tracker != nil {
+		if err := p.tracker.record([]schema.Alert{resolved}); err != nil {
+			return schema.Alert{}, fmt.Errorf("record resolved alert: %w", err)
+		}
+	}
+	return resolved, nil
+}
+
+// pushAlerts posts alerts to every Alertmanager peer, as Alertmanager expects
+// clients to do in a cluster. It succeeds if at least one peer accepts them.
+func (p *PrometheusAlertProvider) pushAlerts(ctx context.Context, amAlerts ...alertmanagerAlert) error {
+	body := make([]postableAlert, 0, len(amAlerts))
+	for _, amAlert := range amAlerts {
+		body = append(body, postableAlert{
+			Labels:       amAlert.Labels,
+			Annotations:  amAlert.Annotations,
+			StartsAt:     amAlert.StartsAt,
+			EndsAt:       amAlert.EndsAt,
+			GeneratorURL: amAlert.GeneratorURL,
+		})
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("encode alerts: %w", err)
+	}
+
+	var lastErr error
+	ok := false
+	for _, base := range p.peerURLs() {
+		if err := p.postJSONTo(ctx, base, "/api/v2/alerts", data); err != nil {
+			lastErr = err
+			continue
+		}
+		ok = true
+	}
+	if !ok {
+		return lastErr
+	}
+	return nil
+}
+
+// postJSONTo issues a POST with a JSON body against a single Alertmanager peer.
+func (p *PrometheusAlertProvider) postJSONTo(ctx context.Context, base, path string, data []byte) error {
+	req, err := http.NewRequestWithContext(ctx, "POST", base+path, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.do(req)
+	if err != nil {
+		return fmt.Errorf("execute request: %w", apierr.Classify(err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return newAPIError(resp)
+	}
+	return nil
+}
+
+func copyStrings(in map[string]string) map[string]string {
+	out := make(map[string]string, len(in))
+	for k, v := range in {
+		out[k] = v
+	}
+	return out
+}
+
+func setDefault(values map[string]string, key, value string) {
+	if value != "" && values[key] == "" {
+		values[key] = value
+	}
+}
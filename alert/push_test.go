@@ -0,0 +1,144 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-prometheus-adapter/apierr"
+)
+
+func TestCreateAndResolve(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		pushed []postableAlert
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v2/alerts" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if r.Method == http.MethodPost {
+			var body []postableAlert
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Errorf("decode body: %v", err)
+			}
+			pushed = append(pushed, body...)
+			return
+		}
+		// List the most recently pushed alert as active.
+		last := pushed[len(pushed)-1]
+		json.NewEncoder(w).Encode([]map[string]any{{
+			"fingerprint":  labelsFingerprint(last.Labels),
+			"status":       map[string]any{"state": "active"},
+			"labels":       last.Labels,
+			"annotations":  last.Annotations,
+			"startsAt":     last.StartsAt,
+			"generatorURL": last.GeneratorURL,
+		}})
+	}))
+	defer server.Close()
+
+	prov, err := NewPrometheusAlertProvider(map[string]any{
+		"alertmanagerURL": server.URL,
+		"allowWrite":      true,
+	})
+	if err != nil {
+		t.Fatalf("NewPrometheusAlertProvider() error = %v", err)
+	}
+	p := prov.(*PrometheusAlertProvider)
+
+	created, err := p.Create(context.Background(), schema.Alert{
+		Title:       "GameDay",
+		Severity:    "warning",
+		Description: "Synthetic alert",
+		URL:         "http://opsorch/gameday",
+		Fields:      map[string]any{"labels": map[string]any{"team": "sre"}},
+	})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if created.Status != "firing" || created.Title != "GameDay" || created.Severity != "warning" {
+		t.Errorf("created = %+v", created)
+	}
+	if len(pushed) != 1 {
+		t.Fatalf("expected 1 pushed alert, got %d", len(pushed))
+	}
+	if got := pushed[0]; got.Labels["team"] != "sre" || got.Annotations["description"] != "Synthetic alert" || got.EndsAt != "" || got.GeneratorURL != "http://opsorch/gameday" {
+		t.Errorf("pushed = %+v", got)
+	}
+
+	resolved, err := p.Resolve(context.Background(), created.ID)
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if resolved.Status != "resolved" {
+		t.Errorf("Status = %v, want resolved", resolved.Status)
+	}
+	if len(pushed) != 2 {
+		t.Fatalf("expected resolve to re-post the alert, got %d posts", len(pushed))
+	}
+	endsAt, err := time.Parse(time.RFC3339, pushed[1].EndsAt)
+	if err != nil || time.Since(endsAt) > time.Minute {
+		t.Errorf("endsAt = %q, want now", pushed[1].EndsAt)
+	}
+	if labelsFingerprint(pushed[1].Labels) != created.ID {
+		t.Errorf("resolve posted different labels: %v", pushed[1].Labels)
+	}
+}
+
+func TestResolveRejectsPrometheusAlerts(t *testing.T) {
+	labels := map[string]string{"alertname": "HighCPU", "severity": "critical"}
+	posted := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			posted = true
+			return
+		}
+		json.NewEncoder(w).Encode([]map[string]any{{
+			"fingerprint": labelsFingerprint(labels),
+			"status":      map[string]any{"state": "active"},
+			"labels":      labels,
+			"startsAt":    "2025-12-03T10:00:00Z",
+		}})
+	}))
+	defer server.Close()
+
+	prov, err := NewPrometheusAlertProvider(map[string]any{
+		"alertmanagerURL": server.URL,
+		"allowWrite":      true,
+	})
+	if err != nil {
+		t.Fatalf("NewPrometheusAlertProvider() error = %v", err)
+	}
+
+	_, err = prov.(*PrometheusAlertProvider).Resolve(context.Background(), labelsFingerprint(labels))
+	if apierr.CodeOf(err) != apierr.InvalidArgument {
+		t.Errorf("Resolve() error = %v, want invalid_argument", err)
+	}
+	if posted {
+		t.Error("Resolve() posted a Prometheus alert")
+	}
+}
+
+func TestWritesDisabledByDefault(t *testing.T) {
+	prov, err := NewPrometheusAlertProvider(map[string]any{"alertmanagerURL": "http://localhost:9093"})
+	if err != nil {
+		t.Fatalf("NewPrometheusAlertProvider() error = %v", err)
+	}
+	p := prov.(*PrometheusAlertProvider)
+
+	if _, err := p.Create(context.Background(), schema.Alert{Title: "GameDay"}); err != errWritesDisabled {
+		t.Errorf("Create() error = %v, want errWritesDisabled", err)
+	}
+	if _, err := p.Resolve(context.Background(), "abc123"); err != errWritesDisabled {
+		t.Errorf("Resolve() error = %v, want errWritesDisabled", err)
+	}
+}
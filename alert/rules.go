@@ -0,0 +1,305 @@
+package alert
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-prometheus-adapter/apierr"
+	"github.com/opsorch/opsorch-prometheus-adapter/httptransport"
+	"github.com/prometheus/client_golang/api"
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+)
+
+// newRulesAPI creates the optional Prometheus API client used to read alerting
+// rules. It returns nil when prometheusURL is not configured.
+func newRulesAPI(config map[string]any, transport *httptransport.Transport) (v1.API, string, error) {
+	prometheusURL, _ := config["prometheusURL"].(string)
+	if prometheusURL == "" {
+		return nil, "", nil
+	}
+
+	client, err := api.NewClient(api.Config{
+		Address:      prometheusURL,
+		RoundTripper: transport,
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create prometheus client: %w", err)
+	}
+
+	return v1.NewAPI(client), strings.TrimSuffix(prometheusURL, "/"), nil
+}
+
+// ruleAlerts returns the Prometheus rule alerts. When the rules API fails it
+// returns a warning instead, so that an unreachable Prometheus does not hide
+// Alertmanager alerts.
+func (p *PrometheusAlertProvider) ruleAlerts(ctx context.Context) ([]schema.Alert, []string) {
+	alerts, err := p.queryRuleAlerts(ctx)
+	if err != nil {
+		p.log().WarnContext(ctx, "skipping prometheus rule alerts", "url", p.prometheusURL, "code", apierr.CodeOf(err), "error", err)
+		return nil, []string{err.Error()}
+	}
+	return alerts, nil
+}
+
+// queryRuleAlerts fetches pending and firing alerts from Prometheus rule
+// evaluation. Each alerting rule in /api/v1/rules embeds the alerts reported
+// by /api/v1/alerts, so a single call yields both the alerts and the rule
+// expression and for duration that produced them.
+func (p *PrometheusAlertProvider) queryRuleAlerts(ctx context.Context) ([]schema.Alert, error) {
+	if p.rulesAPI == nil {
+		return nil, nil
+	}
+
+	result, err := p.rulesAPI.Rules(ctx)
+	if err != nil {
+		err = apierr.Classify(err)
+		p.log().DebugContext(ctx, "prometheus rules query failed", "url", p.prometheusURL, "code", apierr.CodeOf(err), "error", err)
+		return nil, fmt.Errorf("prometheus rules query failed: %w", err)
+	}
+
+	var alerts []schema.Alert
+	for _, group := range result.Groups {
+		for _, rule := range group.Rules {
+			alertingRule, ok := rule.(v1.AlertingRule)
+			if !ok {
+				continue
+			}
+			for _, ruleAlert := range alertingRule.Alerts {
+				if ruleAlert == nil || ruleAlert.State == v1.AlertStateInactive {
+					continue
+				}
+				alerts = append(alerts, p.convertRuleAlert(group, alertingRule, *ruleAlert))
+			}
+		}
+	}
+
+	return alerts, nil
+}
+
+func (p *PrometheusAlertProvider) convertRuleAlert(group v1.RuleGroup, rule v1.AlertingRule, ruleAlert v1.Alert) schema.Alert {
+	labels := labelSetToMap(ruleAlert.Labels)
+	annotations := labelSetToMap(ruleAlert.Annotations)
+
+	fingerprint := ruleAlert.Labels.Fingerprint().String()
+	alert := schema.Alert{
+		ID:        fingerprint,
+		Status:    string(ruleAlert.State),
+		Severity:  p.severity.normalize(labels),
+		CreatedAt: ruleAlert.ActiveAt,
+		UpdatedAt: rule.LastEvaluation,
+		URL:       p.prometheusGraphURL(rule.Query),
+		Fields: map[string]any{
+			"labels":      labels,
+			"annotations": annotations,
+			"value":       ruleAlert.Value,
+			"expr":        rule.Query,
+			"for":         (time.Duration(rule.Duration) * time.Second).String(),
+			"activeAt":    ruleAlert.ActiveAt,
+			"ruleGroup":   group.Name,
+		},
+		Metadata: map[string]any{
+			"source":      "prometheus",
+			"origin":      "rules",
+			"fingerprint": fingerprint,
+		},
+	}
+
+	p.fields.apply(&alert, labels, annotations)
+
+	return alert
+}
+
+// prometheusGraphURL links to the Prometheus graph of a PromQL expression.
+func (p *PrometheusAlertProvider) prometheusGraphURL(expr string) string {
+	params := url.Values{}
+	params.Set("g0.expr", expr)
+	params.Set("g0.tab", "1")
+	return fmt.Sprintf("%s/graph?%s", p.prometheusURL, params.Encode())
+}
+
+// mergeRuleAlerts merges Prometheus rule alerts into Alertmanager alerts by
+// label-set fingerprint. Alerts known to Alertmanager keep their Alertmanager
+// status and gain the rule fields, while tracked resolved alerts that are
+// active again are replaced. Prometheus adds its external labels only to the
+// alerts it sends to Alertmanager, so a rule alert whose labels are a subset
+// of an active Alertmanager alert's labels is merged into that alert too. The
+// rest, typically pending alerts, are appended.
+func mergeRuleAlerts(alerts, ruleAlerts []schema.Alert) []schema.Alert {
+	if len(ruleAlerts) == 0 {
+		return alerts
+	}
+
+	byID := make(map[string]int, len(alerts))
+	byName := map[string][]int{}
+	for i, alert := range alerts {
+		byID[alert.ID] = i
+		if alert.Status != "resolved" {
+			name := alertLabels(alert)["alertname"]
+			byName[name] = append(byName[name], i)
+		}
+	}
+
+	for _, ruleAlert := range ruleAlerts {
+		i, ok := byID[ruleAlert.ID]
+		if ok && alerts[i].Status == "resolved" {
+			alerts[i] = ruleAlert
+			continue
+		}
+		if !ok {
+			i, ok = matchRuleAlert(alerts, byName, alertLabels(ruleAlert))
+		}
+		if ok {
+			fields := make(map[string]any, len(alerts[i].Fields)+4)
+			for k, v := range alerts[i].Fields {
+				fields[k] = v
+			}
+			for _, key := range []string{"expr", "for", "activeAt", "ruleGroup"} {
+				fields[key] = ruleAlert.Fields[key]
+			}
+			alerts[i].Fields = fields
+			continue
+		}
+		alerts = append(alerts, ruleAlert)
+	}
+
+	return alerts
+}
+
+// matchRuleAlert returns the index of the unresolved alert among candidates,
+// grouped by alertname, whose labels include every label of a rule alert.
+func matchRuleAlert(alerts []schema.Alert, byName map[string][]int, labels map[string]string) (int, bool) {
+	for _, i := range byName[labels["alertname"]] {
+		if containsLabels(alertLabels(alerts[i]), labels) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// containsLabels reports whether labels holds every label in subset.
+func containsLabels(labels, subset map[string]string) bool {
+	for name, value := range subset {
+		if labels[name] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// AlertRule is an alerting or recording rule loaded by Prometheus.
+type AlertRule struct {
+	Name           string            `json:"name"`
+	Type           string            `json:"type"` // alerting or recording
+	Group          string            `json:"group"`
+	File           string            `json:"file"`
+	Expression     string            `json:"expression"`
+	Duration       string            `json:"duration,omitempty"`
+	Labels         map[string]string `json:"labels,omitempty"`
+	Annotations    map[string]string `json:"annotations,omitempty"`
+	State          string            `json:"state,omitempty"` // inactive, pending or firing
+	ActiveAlerts   int               `json:"activeAlerts"`
+	Health         string            `json:"health"`
+	LastError      string            `json:"lastError,omitempty"`
+	LastEvaluation time.Time         `json:"lastEvaluation"`
+}
+
+// Rules lists the alerting and recording rules loaded by Prometheus, filtered
+// by the QueryScope labels used for alerts. A scope label missing from a rule
+// matches when one of the rule's active alerts carries it.
+func (p *PrometheusAlertProvider) Rules(ctx context.Context, scope schema.QueryScope) ([]AlertRule, error) {
+	if p.rulesAPI == nil {
+		return nil, apierr.New(apierr.InvalidArgument, "missing required config field: prometheusURL")
+	}
+
+	result, err := p.rulesAPI.Rules(ctx)
+	if err != nil {
+		err = apierr.Classify(err)
+		p.log().DebugContext(ctx, "prometheus rules query failed", "url", p.prometheusURL, "code", apierr.CodeOf(err), "error", err)
+		return nil, fmt.Errorf("prometheus rules query failed: %w", err)
+	}
+
+	rules := make([]AlertRule, 0)
+	for _, group := range result.Groups {
+		for _, rule := range group.Rules {
+			var (
+				converted AlertRule
+				alerts    []*v1.Alert
+			)
+			switch r := rule.(type) {
+			case v1.AlertingRule:
+				converted = AlertRule{
+					Name:           r.Name,
+					Type:           "alerting",
+					Expression:     r.Query,
+					Duration:       (time.Duration(r.Duration) * time.Second).String(),
+					Labels:         labelSetToMap(r.Labels),
+					Annotations:    labelSetToMap(r.Annotations),
+					State:          r.State,
+					ActiveAlerts:   len(r.Alerts),
+					Health:         string(r.Health),
+					LastError:      r.LastError,
+					LastEvaluation: r.LastEvaluation,
+				}
+				alerts = r.Alerts
+			case v1.RecordingRule:
+				converted = AlertRule{
+					Name:           r.Name,
+					Type:           "recording",
+					Expression:     r.Query,
+					Labels:         labelSetToMap(r.Labels),
+					Health:         string(r.Health),
+					LastError:      r.LastError,
+					LastEvaluation: r.LastEvaluation,
+				}
+			default:
+				continue
+			}
+			converted.Group = group.Name
+			converted.File = group.File
+
+			if ruleMatchesScope(converted.Labels, alerts, scope) {
+				rules = append(rules, converted)
+			}
+		}
+	}
+
+	return rules, nil
+}
+
+// ruleMatchesScope applies scope labels to a rule, falling back to the labels
+// of its active alerts for scope labels the rule does not set itself.
+func ruleMatchesScope(labels map[string]string, alerts []*v1.Alert, scope schema.QueryScope) bool {
+	for label, value := range scopeLabels(scope) {
+		if ruleValue, ok := labels[label]; ok {
+			if ruleValue != value {
+				return false
+			}
+			continue
+		}
+
+		matched := false
+		for _, alert := range alerts {
+			if alert != nil && string(alert.Labels[model.LabelName(label)]) == value {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+func labelSetToMap(set model.LabelSet) map[string]string {
+	out := make(map[string]string, len(set))
+	for k, v := range set {
+		out[string(k)] = string(v)
+	}
+	return out
+}
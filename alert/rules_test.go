@@ -0,0 +1,270 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-prometheus-adapter/apierr"
+)
+
+const testRulesResponse = `{
+	"status": "success",
+	"data": {
+		"groups": [
+			{
+				"name": "api.rules",
+				"file": "/etc/prometheus/rules/api.yml",
+				"interval": 30,
+				"rules": [
+					{
+						"type": "alerting",
+						"name": "HighCPU",
+						"query": "cpu_usage > 0.9",
+						"duration": 300,
+						"labels": {"severity": "critical"},
+						"annotations": {"description": "CPU usage above 90%"},
+						"alerts": [
+							{
+								"labels": {"alertname": "HighCPU", "severity": "critical", "service": "api"},
+								"annotations": {"description": "CPU usage above 90%"},
+								"state": "firing",
+								"activeAt": "2025-12-03T09:55:00Z",
+								"value": "0.95"
+							},
+							{
+								"labels": {"alertname": "HighCPU", "severity": "critical", "service": "web"},
+								"annotations": {"description": "CPU usage above 90%"},
+								"state": "pending",
+								"activeAt": "2025-12-03T10:02:00Z",
+								"value": "0.91"
+							}
+						],
+						"health": "ok",
+						"lastEvaluation": "2025-12-03T10:05:00Z",
+						"evaluationTime": 0.001,
+						"state": "firing"
+					},
+					{
+						"type": "recording",
+						"name": "job:cpu_usage:avg",
+						"query": "avg by (job) (cpu_usage)",
+						"health": "ok",
+						"lastEvaluation": "2025-12-03T10:05:00Z",
+						"evaluationTime": 0.001
+					}
+				]
+			}
+		]
+	}
+}`
+
+// newRulesTestServer serves testRulesResponse and the firing HighCPU alert
+// from Alertmanager, with externalLabels added to the Alertmanager copy as
+// Prometheus does.
+func newRulesTestServer(t *testing.T, externalLabels map[string]string) *httptest.Server {
+	labels := map[string]string{"alertname": "HighCPU", "severity": "critical", "service": "api"}
+	for name, value := range externalLabels {
+		labels[name] = value
+	}
+	firing := labelsFingerprint(labels)
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/rules":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(testRulesResponse))
+		case "/api/v2/alerts":
+			json.NewEncoder(w).Encode([]map[string]any{
+				{
+					"fingerprint": firing,
+					"status":      map[string]any{"state": "active"},
+					"labels":      labels,
+					"annotations": map[string]string{"description": "CPU usage above 90%"},
+					"startsAt":    "2025-12-03T10:00:00Z",
+					"updatedAt":   "2025-12-03T10:05:00Z",
+				},
+			})
+		default:
+			t.Errorf("unexpected request path %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestQueryMergesRuleAlerts(t *testing.T) {
+	server := newRulesTestServer(t, nil)
+	defer server.Close()
+
+	prov, err := NewPrometheusAlertProvider(map[string]any{
+		"alertmanagerURL": server.URL,
+		"prometheusURL":   server.URL,
+	})
+	if err != nil {
+		t.Fatalf("NewPrometheusAlertProvider() error = %v", err)
+	}
+
+	alerts, err := prov.Query(context.Background(), schema.AlertQuery{})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(alerts) != 2 {
+		t.Fatalf("expected firing alert merged with pending alert, got %d alerts", len(alerts))
+	}
+
+	// Alerts are sorted newest first, so the pending alert comes first.
+	firing := alerts[1]
+	if firing.Status != "firing" || firing.Metadata["origin"] != nil {
+		t.Errorf("expected Alertmanager alert last, got %+v", firing)
+	}
+	if firing.Fields["expr"] != "cpu_usage > 0.9" || firing.Fields["for"] != "5m0s" {
+		t.Errorf("expected rule fields on merged alert, got expr=%v for=%v", firing.Fields["expr"], firing.Fields["for"])
+	}
+
+	pending := alerts[0]
+	if pending.Status != "pending" {
+		t.Errorf("Status = %v, want pending", pending.Status)
+	}
+	if pending.Service != "web" {
+		t.Errorf("Service = %v, want web", pending.Service)
+	}
+	if pending.CreatedAt.IsZero() {
+		t.Error("expected CreatedAt from activeAt")
+	}
+
+	alerts, err = prov.Query(context.Background(), schema.AlertQuery{Statuses: []string{"pending"}})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(alerts) != 1 || alerts[0].Service != "web" {
+		t.Fatalf("pending alerts = %+v, want the web alert", alerts)
+	}
+
+	alert, err := prov.Get(context.Background(), pending.ID)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if alert.Status != "pending" {
+		t.Errorf("Get() Status = %v, want pending", alert.Status)
+	}
+}
+
+func TestQueryMergesRuleAlertsWithExternalLabels(t *testing.T) {
+	server := newRulesTestServer(t, map[string]string{"cluster": "prod"})
+	defer server.Close()
+
+	prov, err := NewPrometheusAlertProvider(map[string]any{
+		"alertmanagerURL": server.URL,
+		"prometheusURL":   server.URL,
+	})
+	if err != nil {
+		t.Fatalf("NewPrometheusAlertProvider() error = %v", err)
+	}
+
+	alerts, err := prov.Query(context.Background(), schema.AlertQuery{Statuses: []string{"firing"}})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(alerts) != 1 {
+		t.Fatalf("expected the firing alert once, got %d alerts", len(alerts))
+	}
+	if alert := alerts[0]; alert.Metadata["origin"] != nil || alert.Fields["expr"] != "cpu_usage > 0.9" || alertLabels(alert)["cluster"] != "prod" {
+		t.Errorf("expected the Alertmanager alert with rule fields, got %+v", alert)
+	}
+}
+
+func TestQueryWithoutPrometheus(t *testing.T) {
+	server := newRulesTestServer(t, nil)
+	defer server.Close()
+	prometheus := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer prometheus.Close()
+
+	prov, err := NewPrometheusAlertProvider(map[string]any{
+		"alertmanagerURL": server.URL,
+		"prometheusURL":   prometheus.URL,
+	})
+	if err != nil {
+		t.Fatalf("NewPrometheusAlertProvider() error = %v", err)
+	}
+
+	alerts, err := prov.Query(context.Background(), schema.AlertQuery{})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(alerts) != 1 || alerts[0].Status != "firing" {
+		t.Fatalf("alerts = %+v, want the Alertmanager alert", alerts)
+	}
+
+	if _, err := prov.Get(context.Background(), alerts[0].ID); err != nil {
+		t.Errorf("Get() error = %v", err)
+	}
+
+	p := prov.(*PrometheusAlertProvider)
+	if _, warnings, err := p.QueryWithWarnings(context.Background(), schema.AlertQuery{}); err != nil || len(warnings) != 1 {
+		t.Errorf("QueryWithWarnings() warnings = %v, error = %v, want the rules failure", warnings, err)
+	}
+	if _, warnings, err := p.GetWithWarnings(context.Background(), alerts[0].ID); err != nil || len(warnings) != 1 {
+		t.Errorf("GetWithWarnings() warnings = %v, error = %v, want the rules failure", warnings, err)
+	}
+	if _, err := prov.Get(context.Background(), "missing"); apierr.CodeOf(err) != apierr.Unavailable {
+		t.Errorf("Get(missing) error = %v, want unavailable", err)
+	}
+}
+
+func TestRules(t *testing.T) {
+	server := newRulesTestServer(t, nil)
+	defer server.Close()
+
+	prov, err := NewPrometheusAlertProvider(map[string]any{
+		"alertmanagerURL": server.URL,
+		"prometheusURL":   server.URL,
+	})
+	if err != nil {
+		t.Fatalf("NewPrometheusAlertProvider() error = %v", err)
+	}
+	catalog := prov.(*PrometheusAlertProvider)
+
+	rules, err := catalog.Rules(context.Background(), schema.QueryScope{})
+	if err != nil {
+		t.Fatalf("Rules() error = %v", err)
+	}
+	if len(rules) != 2 {
+		t.Fatalf("expected alerting and recording rule, got %d", len(rules))
+	}
+
+	alerting := rules[0]
+	if alerting.Type != "alerting" || alerting.Name != "HighCPU" {
+		t.Errorf("rule = %+v, want alerting rule HighCPU", alerting)
+	}
+	if alerting.Group != "api.rules" || alerting.File != "/etc/prometheus/rules/api.yml" {
+		t.Errorf("group/file = %s %s", alerting.Group, alerting.File)
+	}
+	if alerting.Expression != "cpu_usage > 0.9" || alerting.Duration != "5m0s" {
+		t.Errorf("expression/duration = %s %s", alerting.Expression, alerting.Duration)
+	}
+	if alerting.Health != "ok" || alerting.ActiveAlerts != 2 || alerting.LastEvaluation.IsZero() {
+		t.Errorf("health/activeAlerts/lastEvaluation = %s %d %v", alerting.Health, alerting.ActiveAlerts, alerting.LastEvaluation)
+	}
+	if rules[1].Type != "recording" {
+		t.Errorf("Type = %s, want recording", rules[1].Type)
+	}
+
+	rules, err = catalog.Rules(context.Background(), schema.QueryScope{Service: "web"})
+	if err != nil {
+		t.Fatalf("Rules() error = %v", err)
+	}
+	if len(rules) != 1 || rules[0].Name != "HighCPU" {
+		t.Fatalf("scoped rules = %+v, want HighCPU via its alert labels", rules)
+	}
+}
+
+func TestRulesRequiresPrometheusURL(t *testing.T) {
+	prov := &PrometheusAlertProvider{baseURL: "http://alertmanager:9093", client: &http.Client{}}
+	if _, err := prov.Rules(context.Background(), schema.QueryScope{}); err == nil {
+		t.Fatal("expected error without prometheusURL")
+	}
+}
@@ -0,0 +1,119 @@
+package alert
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/opsorch/opsorch-core/schema"
+)
+
+// searchTerm is one compiled search term. A term with a key only matches the
+// label or annotation of that name; otherwise any text field may match.
+type searchTerm struct {
+	key   string
+	value string // lower-cased
+}
+
+// alertSearch is a compiled free-text search; every term must match.
+type alertSearch []searchTerm
+
+// compileSearch parses a search string into terms. Terms are separated by
+// whitespace, double quotes group phrases, and key:value restricts a term to
+// the label or annotation named key, e.g. `instance:node-1 "disk full"`.
+func compileSearch(query string) (alertSearch, error) {
+	var (
+		terms   alertSearch
+		current strings.Builder
+		key     string
+		quoted  bool
+		started bool
+	)
+	flush := func() {
+		if started && (current.Len() > 0 || key != "") {
+			terms = append(terms, searchTerm{key: key, value: strings.ToLower(current.String())})
+		}
+		current.Reset()
+		key = ""
+		started = false
+	}
+
+	for _, r := range query {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			started = true
+		case quoted:
+			current.WriteRune(r)
+		case unicode.IsSpace(r):
+			flush()
+		case r == ':' && key == "" && current.Len() > 0 && isLabelName(current.String()):
+			key = current.String()
+			current.Reset()
+		default:
+			current.WriteRune(r)
+			started = true
+		}
+	}
+	if quoted {
+		return nil, fmt.Errorf("invalid search: unterminated quote")
+	}
+	flush()
+
+	for _, term := range terms {
+		if term.value == "" {
+			return nil, fmt.Errorf("invalid search: missing value for %s", term.key)
+		}
+	}
+	return terms, nil
+}
+
+// isLabelName reports whether s is a valid Prometheus label name.
+func isLabelName(s string) bool {
+	for i, r := range s {
+		if r != '_' && !unicode.IsLetter(r) && (i == 0 || !unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// matches reports whether every term matches the alert's title, description,
+// labels or annotations, case-insensitively.
+func (s alertSearch) matches(alert schema.Alert) bool {
+	if len(s) == 0 {
+		return true
+	}
+
+	labels := alertLabels(alert)
+	annotations := stringMapField(alert, "annotations")
+	for _, term := range s {
+		if term.key != "" {
+			if !containsFold(labels[term.key], term.value) && !containsFold(annotations[term.key], term.value) {
+				return false
+			}
+			continue
+		}
+		if !containsFold(alert.Title, term.value) &&
+			!containsFold(alert.Description, term.value) &&
+			!anyValueContains(labels, term.value) &&
+			!anyValueContains(annotations, term.value) {
+			return false
+		}
+	}
+	return true
+}
+
+func anyValueContains(values map[string]string, lowered string) bool {
+	for _, v := range values {
+		if containsFold(v, lowered) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsFold reports whether s contains the already lower-cased substring.
+func containsFold(s, lowered string) bool {
+	return s != "" && strings.Contains(strings.ToLower(s), lowered)
+}
@@ -0,0 +1,103 @@
+package alert
+
+import (
+	"context"
+	"testing"
+
+	"github.com/opsorch/opsorch-core/schema"
+)
+
+func TestCompileSearch(t *testing.T) {
+	search, err := compileSearch(`  Disk instance:"node 1" "out of SPACE" `)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := alertSearch{
+		{value: "disk"},
+		{key: "instance", value: "node 1"},
+		{value: "out of space"},
+	}
+	if len(search) != len(want) {
+		t.Fatalf("terms = %+v, want %+v", search, want)
+	}
+	for i := range want {
+		if search[i] != want[i] {
+			t.Errorf("term %d = %+v, want %+v", i, search[i], want[i])
+		}
+	}
+
+	for _, bad := range []string{`"unterminated`, `instance:`} {
+		if _, err := compileSearch(bad); err == nil {
+			t.Errorf("compileSearch(%q) expected error", bad)
+		}
+	}
+}
+
+func TestAlertSearchMatches(t *testing.T) {
+	alert := schema.Alert{
+		Title: "DiskFull",
+		Fields: map[string]any{
+			"labels":      map[string]string{"alertname": "DiskFull", "instance": "db-01.prod"},
+			"annotations": map[string]any{"description": "Volume /data has no space left"},
+		},
+	}
+
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{query: "", want: true},
+		{query: "diskfull", want: true},
+		{query: "DB-01", want: true},
+		{query: `"no space left"`, want: true},
+		{query: "instance:db-01 disk", want: true},
+		{query: "instance:db-02", want: false},
+		{query: "description:space", want: true},
+		{query: "disk memory", want: false},
+	}
+
+	for _, tt := range tests {
+		search, err := compileSearch(tt.query)
+		if err != nil {
+			t.Fatalf("compileSearch(%q) error = %v", tt.query, err)
+		}
+		if got := search.matches(alert); got != tt.want {
+			t.Errorf("matches(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestQueryPageSearch(t *testing.T) {
+	p := newPageTestProvider(t)
+
+	page, err := p.QueryPage(context.Background(), schema.AlertQuery{}, QueryOptions{Search: "severity:critical"})
+	if err != nil {
+		t.Fatalf("QueryPage() error = %v", err)
+	}
+	if got := alertIDs(page.Alerts); !equalStrings(got, []string{"d", "b"}) || page.Total != 2 {
+		t.Errorf("alerts = %v total=%d, want [d b]", got, page.Total)
+	}
+}
+
+func TestQuerySearch(t *testing.T) {
+	p := newPageTestProvider(t)
+
+	for _, query := range []schema.AlertQuery{
+		{Query: "severity:critical"},
+		{Metadata: map[string]any{"search": "severity:critical"}},
+	} {
+		alerts, err := p.Query(context.Background(), query)
+		if err != nil {
+			t.Fatalf("Query(%+v) error = %v", query, err)
+		}
+		if got := alertIDs(alerts); !equalStrings(got, []string{"d", "b"}) {
+			t.Errorf("Query(%+v) = %v, want [d b]", query, got)
+		}
+	}
+
+	// A query search and a page search must both match.
+	page, err := p.QueryPage(context.Background(), schema.AlertQuery{Query: "severity:critical"}, QueryOptions{Search: "nomatch"})
+	if err != nil || page.Total != 0 {
+		t.Errorf("QueryPage() = %+v, %v, want no alerts", page, err)
+	}
+}
@@ -0,0 +1,189 @@
+package alert
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-prometheus-adapter/httptransport"
+	"github.com/opsorch/opsorch-prometheus-adapter/metric"
+)
+
+// Series enrichment modes supported by the seriesEnrichment config field.
+const (
+	// SeriesModeSeries attaches the full range query result to Fields["series"].
+	SeriesModeSeries = "series"
+	// SeriesModeSummary attaches a graph link and summary statistics only.
+	SeriesModeSummary = "summary"
+)
+
+// maxSeriesPoints bounds the number of points per series in enrichment queries.
+const maxSeriesPoints = 250
+
+// seriesEnricher attaches the metric series behind an alert's expression.
+// Queries only go to the configured prometheusURL, never to a host named in
+// the alert, since they carry the provider's credentials.
+type seriesEnricher struct {
+	mode          string
+	lookback      time.Duration
+	prometheusURL string
+	provider      *metric.PrometheusProvider
+	now           func() time.Time
+}
+
+// SeriesSummary summarizes the series returned for an alert's expression.
+type SeriesSummary struct {
+	Series int     `json:"series"`
+	Points int     `json:"points"`
+	Min    float64 `json:"min"`
+	Max    float64 `json:"max"`
+	Avg    float64 `json:"avg"`
+	Last   float64 `json:"last"`
+}
+
+// newSeriesEnricherFromConfig reads seriesEnrichment and seriesLookback. It
+// returns nil when enrichment is disabled or prometheusURL is unset.
+func newSeriesEnricherFromConfig(config map[string]any, prometheusURL string, transport *httptransport.Transport) (*seriesEnricher, error) {
+	mode, _ := config["seriesEnrichment"].(string)
+	switch mode {
+	case "":
+		return nil, nil
+	case SeriesModeSeries, SeriesModeSummary:
+	default:
+		return nil, fmt.Errorf("unsupported seriesEnrichment: %s", mode)
+	}
+
+	lookback, err := durationConfig(config, "seriesLookback", time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	if prometheusURL == "" {
+		return nil, nil
+	}
+
+	provider, err := metric.NewPrometheusProviderWithTransport(map[string]any{"url": prometheusURL}, transport)
+	if err != nil {
+		return nil, err
+	}
+
+	return &seriesEnricher{
+		mode:          mode,
+		lookback:      lookback,
+		prometheusURL: strings.TrimSuffix(prometheusURL, "/"),
+		provider:      provider,
+		now:           time.Now,
+	}, nil
+}
+
+// enrich runs the alert's expression as a range query from lookback before
+// startsAt until the alert ended, or now, and attaches the result. Failures
+// are recorded in Metadata["seriesError"] rather than failing the lookup, and
+// Prometheus warnings in Metadata["seriesWarnings"].
+func (e *seriesEnricher) enrich(ctx context.Context, alert *schema.Alert) {
+	expr := e.expression(*alert)
+	if expr == "" {
+		return
+	}
+
+	end := e.now()
+	if endsAt, ok := alert.Fields["endsAt"].(time.Time); ok && endsAt.Before(end) {
+		end = endsAt
+	}
+	start := end.Add(-e.lookback)
+	if !alert.CreatedAt.IsZero() && alert.CreatedAt.Before(end) {
+		start = alert.CreatedAt.Add(-e.lookback)
+	}
+	step := end.Sub(start) / maxSeriesPoints
+	if step < 15*time.Second {
+		step = 15 * time.Second
+	}
+
+	series, warnings, err := e.query(ctx, expr, start, end, step)
+	if err != nil {
+		alert.Metadata["seriesError"] = err.Error()
+		return
+	}
+	if len(warnings) > 0 {
+		// The series are usable; keep Prometheus' warnings with them.
+		alert.Metadata["seriesWarnings"] = strings.Join(warnings, "; ")
+	}
+
+	alert.Fields["seriesURL"] = seriesGraphURL(e.prometheusURL, expr, start, end)
+	alert.Fields["seriesSummary"] = summarizeSeries(series)
+	if e.mode == SeriesModeSeries {
+		alert.Fields["series"] = series
+	}
+}
+
+// expression returns the expression behind an alert: the g0.expr of its
+// generatorURL, or the rule expression for rule alerts. Only the expression
+// is taken from the generatorURL, never its host.
+func (e *seriesEnricher) expression(alert schema.Alert) string {
+	var expr string
+	if generatorURL, ok := alert.Fields["generatorURL"].(string); ok && generatorURL != "" {
+		if u, err := url.Parse(generatorURL); err == nil {
+			expr = u.Query().Get("g0.expr")
+		}
+	}
+	if expr == "" {
+		expr, _ = alert.Fields["expr"].(string)
+	}
+	return expr
+}
+
+func (e *seriesEnricher) query(ctx context.Context, expr string, start, end time.Time, step time.Duration) ([]schema.MetricSeries, []string, error) {
+	return e.provider.QueryWithWarnings(ctx, schema.MetricQuery{
+		Start:    start,
+		End:      end,
+		Step:     int(step / time.Second),
+		Metadata: map[string]any{"query": expr},
+	})
+}
+
+// close releases the metric provider's connections.
+func (e *seriesEnricher) close() {
+	_ = e.provider.Close()
+}
+
+// seriesGraphURL links to the Prometheus graph of expr over the queried window.
+func seriesGraphURL(base, expr string, start, end time.Time) string {
+	params := url.Values{}
+	params.Set("g0.expr", expr)
+	params.Set("g0.tab", "0")
+	params.Set("g0.range_input", fmt.Sprintf("%ds", int(end.Sub(start).Seconds())))
+	params.Set("g0.end_input", end.UTC().Format("2006-01-02 15:04:05"))
+	return base + "/graph?" + params.Encode()
+}
+
+func summarizeSeries(series []schema.MetricSeries) SeriesSummary {
+	summary := SeriesSummary{Series: len(series), Min: math.Inf(1), Max: math.Inf(-1)}
+	var (
+		sum      float64
+		lastTime time.Time
+	)
+	for _, s := range series {
+		for _, point := range s.Points {
+			if math.IsNaN(point.Value) {
+				continue
+			}
+			summary.Points++
+			sum += point.Value
+			summary.Min = math.Min(summary.Min, point.Value)
+			summary.Max = math.Max(summary.Max, point.Value)
+			if !point.Timestamp.Before(lastTime) {
+				lastTime = point.Timestamp
+				summary.Last = point.Value
+			}
+		}
+	}
+	if summary.Points == 0 {
+		summary.Min, summary.Max = 0, 0
+		return summary
+	}
+	summary.Avg = sum / float64(summary.Points)
+	return summary
+}
@@ -0,0 +1,110 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGetEnrichesSeries(t *testing.T) {
+	prometheus := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/rules" {
+			w.Write([]byte(`{"status":"success","data":{"groups":[]}}`))
+			return
+		}
+		if r.URL.Path != "/api/v1/query_range" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		r.ParseForm()
+		if got := r.Form.Get("query"); got != "cpu_usage > 0.9" {
+			t.Errorf("query = %q, want the generatorURL expression", got)
+		}
+		w.Write([]byte(`{
+			"status": "success",
+			"data": {
+				"resultType": "matrix",
+				"result": [
+					{"metric": {"instance": "a"}, "values": [[1764756000, "0.5"], [1764756060, "0.95"]]},
+					{"metric": {"instance": "b"}, "values": [[1764756000, "0.7"], [1764756120, "0.92"]]}
+				]
+			}
+		}`))
+	}))
+	defer prometheus.Close()
+
+	// Only the expression is taken from the generatorURL; its host is never queried.
+	generatorURL := "http://generator.invalid/graph?g0.expr=" + url.QueryEscape("cpu_usage > 0.9") + "&g0.tab=1"
+	alertmanager := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode([]map[string]any{{
+			"fingerprint":  "abc123",
+			"status":       map[string]any{"state": "active"},
+			"labels":       map[string]string{"alertname": "HighCPU"},
+			"annotations":  map[string]string{},
+			"startsAt":     "2025-12-03T10:00:00Z",
+			"generatorURL": generatorURL,
+		}})
+	}))
+	defer alertmanager.Close()
+
+	for _, mode := range []string{SeriesModeSummary, SeriesModeSeries} {
+		t.Run(mode, func(t *testing.T) {
+			prov, err := NewPrometheusAlertProvider(map[string]any{
+				"alertmanagerURL":  alertmanager.URL,
+				"prometheusURL":    prometheus.URL,
+				"seriesEnrichment": mode,
+				"seriesLookback":   "30m",
+			})
+			if err != nil {
+				t.Fatalf("NewPrometheusAlertProvider() error = %v", err)
+			}
+			prov.(*PrometheusAlertProvider).series.now = func() time.Time {
+				return time.Date(2025, 12, 3, 10, 30, 0, 0, time.UTC)
+			}
+
+			alert, err := prov.Get(context.Background(), "abc123")
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			if alert.Metadata["seriesError"] != nil {
+				t.Fatalf("seriesError = %v", alert.Metadata["seriesError"])
+			}
+
+			summary, ok := alert.Fields["seriesSummary"].(SeriesSummary)
+			if !ok {
+				t.Fatalf("seriesSummary = %#v", alert.Fields["seriesSummary"])
+			}
+			if summary.Series != 2 || summary.Points != 4 || summary.Min != 0.5 || summary.Max != 0.95 || summary.Last != 0.92 {
+				t.Errorf("summary = %+v", summary)
+			}
+			if seriesURL, _ := alert.Fields["seriesURL"].(string); !strings.HasPrefix(seriesURL, prometheus.URL+"/graph?") {
+				t.Errorf("seriesURL = %q, want a link to prometheusURL", seriesURL)
+			}
+			if _, ok := alert.Fields["series"]; ok != (mode == SeriesModeSeries) {
+				t.Errorf("series attached = %v in %s mode", ok, mode)
+			}
+			if err := prov.(*PrometheusAlertProvider).Close(); err != nil {
+				t.Errorf("Close() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestNewSeriesEnricherFromConfig(t *testing.T) {
+	enricher, err := newSeriesEnricherFromConfig(map[string]any{}, "", nil)
+	if err != nil || enricher != nil {
+		t.Errorf("expected enrichment disabled by default, got %v, %v", enricher, err)
+	}
+	if _, err := newSeriesEnricherFromConfig(map[string]any{"seriesEnrichment": "graph"}, "", nil); err == nil {
+		t.Error("expected error for unknown seriesEnrichment")
+	}
+	enricher, err = newSeriesEnricherFromConfig(map[string]any{"seriesEnrichment": SeriesModeSummary}, "", nil)
+	if err != nil || enricher != nil {
+		t.Errorf("expected enrichment skipped without prometheusURL, got %v, %v", enricher, err)
+	}
+}
@@ -0,0 +1,133 @@
+package alert
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// defaultSeverityLabel is the label read for severity when severityLabels is not configured.
+const defaultSeverityLabel = "severity"
+
+// severityMapping normalizes team-specific severity labels into the severity
+// set OpsOrch expects.
+type severityMapping struct {
+	labels   []string          // labels checked in order
+	values   map[string]string // lower-cased raw value -> normalized severity
+	fallback string            // severity for alerts without any severity label
+}
+
+// newSeverityMappingFromConfig reads severityLabels, severityMapping and defaultSeverity.
+func newSeverityMappingFromConfig(config map[string]any) (severityMapping, error) {
+	labels, err := stringListConfig(config, "severityLabels")
+	if err != nil {
+		return severityMapping{}, err
+	}
+
+	values := map[string]string{}
+	switch v := config["severityMapping"].(type) {
+	case nil:
+	case map[string]any:
+		for raw, normalized := range v {
+			s, ok := normalized.(string)
+			if !ok {
+				return severityMapping{}, fmt.Errorf("invalid severityMapping: value for %q must be a string", raw)
+			}
+			values[strings.ToLower(raw)] = s
+		}
+	case map[string]string:
+		for raw, normalized := range v {
+			values[strings.ToLower(raw)] = normalized
+		}
+	default:
+		return severityMapping{}, fmt.Errorf("invalid severityMapping: expected object")
+	}
+
+	fallback, _ := config["defaultSeverity"].(string)
+
+	return severityMapping{labels: labels, values: values, fallback: fallback}, nil
+}
+
+// sourceLabels returns the labels checked for severity, in order.
+func (m severityMapping) sourceLabels() []string {
+	if len(m.labels) == 0 {
+		return []string{defaultSeverityLabel}
+	}
+	return m.labels
+}
+
+// normalize returns the normalized severity of an alert's labels. Values
+// without a mapping are passed through unchanged.
+func (m severityMapping) normalize(labels map[string]string) string {
+	for _, label := range m.sourceLabels() {
+		raw := labels[label]
+		if raw == "" {
+			continue
+		}
+		if normalized, ok := m.values[strings.ToLower(raw)]; ok {
+			return normalized
+		}
+		return raw
+	}
+	return m.fallback
+}
+
+// rawValues returns the label values that normalize to the given severities.
+func (m severityMapping) rawValues(severities []string) []string {
+	wanted := make(map[string]bool, len(severities))
+	for _, severity := range severities {
+		wanted[severity] = true
+	}
+
+	set := map[string]bool{}
+	for _, severity := range severities {
+		// An unmapped value passes through normalize unchanged, so the
+		// severity itself matches unless it is remapped to something else.
+		if mapped, ok := m.values[strings.ToLower(severity)]; !ok || wanted[mapped] {
+			set[severity] = true
+		}
+	}
+	for raw, normalized := range m.values {
+		if wanted[normalized] {
+			set[raw] = true
+		}
+	}
+
+	values := make([]string, 0, len(set))
+	for value := range set {
+		values = append(values, value)
+	}
+	sort.Strings(values)
+	return values
+}
+
+// filter returns an Alertmanager matcher selecting alerts whose raw severity
+// normalizes to one of the given severities. It reports false when the match
+// cannot be expressed as a single matcher, in which case severities must be
+// filtered client-side only.
+func (m severityMapping) filter(severities []string) (string, bool) {
+	labels := m.sourceLabels()
+	if len(severities) == 0 || len(labels) != 1 {
+		return "", false
+	}
+	for _, severity := range severities {
+		if m.fallback != "" && severity == m.fallback {
+			// Alerts without the label normalize to the fallback.
+			return "", false
+		}
+	}
+
+	values := m.rawValues(severities)
+	quoted := make([]string, 0, len(values))
+	for _, value := range values {
+		quoted = append(quoted, regexp.QuoteMeta(value))
+	}
+	pattern := strings.Join(quoted, "|")
+	if len(m.values) > 0 {
+		// Mapping keys are matched case-insensitively.
+		pattern = "(?i)" + pattern
+	}
+
+	return fmt.Sprintf("%s=~%q", labels[0], pattern), true
+}
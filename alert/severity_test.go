@@ -0,0 +1,125 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/opsorch/opsorch-core/schema"
+)
+
+func TestSeverityMappingNormalize(t *testing.T) {
+	mapping, err := newSeverityMappingFromConfig(map[string]any{
+		"severityLabels":  []any{"severity", "priority", "level"},
+		"severityMapping": map[string]any{"P1": "critical", "page": "critical", "crit": "critical", "ticket": "warning"},
+		"defaultSeverity": "info",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		labels map[string]string
+		want   string
+	}{
+		{name: "mapped value", labels: map[string]string{"severity": "page"}, want: "critical"},
+		{name: "case-insensitive mapping", labels: map[string]string{"severity": "p1"}, want: "critical"},
+		{name: "fallback label", labels: map[string]string{"priority": "ticket"}, want: "warning"},
+		{name: "first label wins", labels: map[string]string{"severity": "crit", "level": "ticket"}, want: "critical"},
+		{name: "unmapped passes through", labels: map[string]string{"level": "warning"}, want: "warning"},
+		{name: "default", labels: map[string]string{"alertname": "Watchdog"}, want: "info"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mapping.normalize(tt.labels); got != tt.want {
+				t.Errorf("normalize() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSeverityMappingFilter(t *testing.T) {
+	mapping, err := newSeverityMappingFromConfig(map[string]any{
+		"severityMapping": map[string]any{"P1": "critical", "page": "critical", "P3": "warning"},
+		"defaultSeverity": "info",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	filter, ok := mapping.filter([]string{"critical"})
+	if !ok {
+		t.Fatal("expected server-side filter")
+	}
+	if want := `severity=~"(?i)critical|p1|page"`; filter != want {
+		t.Errorf("filter = %v, want %v", filter, want)
+	}
+
+	if _, ok := mapping.filter([]string{"info"}); ok {
+		t.Error("expected no server-side filter when the default severity is requested")
+	}
+
+	multi, err := newSeverityMappingFromConfig(map[string]any{"severityLabels": "severity,priority"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := multi.filter([]string{"critical"}); ok {
+		t.Error("expected no server-side filter with several severity labels")
+	}
+
+	var zero severityMapping
+	filter, ok = zero.filter([]string{"critical", "warning"})
+	if !ok || filter != `severity=~"critical|warning"` {
+		t.Errorf("filter = %v, %v, want severity=~\"critical|warning\"", filter, ok)
+	}
+}
+
+func TestQueryNormalizesSeverity(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v2/alerts" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if got := r.URL.Query()["filter"]; len(got) != 1 || got[0] != `severity=~"(?i)critical|p1"` {
+			t.Errorf("filter = %v", got)
+		}
+		json.NewEncoder(w).Encode([]map[string]any{
+			{
+				"fingerprint": "abc123",
+				"status":      map[string]any{"state": "active"},
+				"labels":      map[string]string{"alertname": "HighCPU", "severity": "P1"},
+				"annotations": map[string]string{},
+			},
+			{
+				"fingerprint": "def456",
+				"status":      map[string]any{"state": "active"},
+				"labels":      map[string]string{"alertname": "Lowercase", "severity": "Critical"},
+				"annotations": map[string]string{},
+			},
+		})
+	}))
+	defer server.Close()
+
+	prov, err := NewPrometheusAlertProvider(map[string]any{
+		"alertmanagerURL": server.URL,
+		"severityMapping": map[string]any{"P1": "critical"},
+	})
+	if err != nil {
+		t.Fatalf("NewPrometheusAlertProvider() error = %v", err)
+	}
+
+	alerts, err := prov.Query(context.Background(), schema.AlertQuery{Severities: []string{"critical"}})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(alerts) != 1 || alerts[0].ID != "abc123" {
+		t.Fatalf("alerts = %+v, want only the mapped P1 alert", alerts)
+	}
+	if alerts[0].Severity != "critical" {
+		t.Errorf("Severity = %v, want critical", alerts[0].Severity)
+	}
+}
@@ -0,0 +1,455 @@
+package alert
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/opsorch/opsorch-core/schema"
+)
+
+// defaultStateRetention is how long resolved alerts are kept when no retention is configured.
+const defaultStateRetention = 24 * time.Hour
+
+// TrackedAlert is the state the alert provider remembers about a fingerprint.
+type TrackedAlert struct {
+	Alert      schema.Alert `json:"alert"`
+	EndsAt     time.Time    `json:"endsAt,omitempty"`
+	LastSeen   time.Time    `json:"lastSeen"`
+	ResolvedAt time.Time    `json:"resolvedAt,omitempty"`
+}
+
+// StateStore persists tracked alerts keyed by fingerprint.
+type StateStore interface {
+	Load() (map[string]TrackedAlert, error)
+	Save(state map[string]TrackedAlert) error
+}
+
+// StateUpdater is implemented by stores that can apply a load-modify-save
+// cycle atomically, including against other processes sharing the store.
+type StateUpdater interface {
+	Update(fn func(state map[string]TrackedAlert)) error
+}
+
+// NewMemoryStateStore returns a StateStore that keeps state in process memory.
+func NewMemoryStateStore() StateStore {
+	return &memoryStateStore{state: map[string]TrackedAlert{}}
+}
+
+type memoryStateStore struct {
+	mu    sync.Mutex
+	state map[string]TrackedAlert
+}
+
+func (s *memoryStateStore) Load() (map[string]TrackedAlert, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state := make(map[string]TrackedAlert, len(s.state))
+	for fp, rec := range s.state {
+		state[fp] = rec
+	}
+	return state, nil
+}
+
+func (s *memoryStateStore) Save(state map[string]TrackedAlert) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.state = make(map[string]TrackedAlert, len(state))
+	for fp, rec := range state {
+		s.state[fp] = rec
+	}
+	return nil
+}
+
+// NewFileStateStore returns a StateStore that keeps state in a local JSON file.
+// Writes go to a temporary file that is renamed into place. Updates hold an
+// exclusive lock on path+".lock", so the alert plugin and the webhook
+// receiver can share one file.
+func NewFileStateStore(path string) StateStore {
+	return &fileStateStore{path: path}
+}
+
+type fileStateStore struct {
+	mu   sync.Mutex
+	path string
+}
+
+func (s *fileStateStore) Load() (map[string]TrackedAlert, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.load()
+}
+
+func (s *fileStateStore) Save(state map[string]TrackedAlert) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.save(state)
+}
+
+// Update loads, modifies and saves the state while holding the lock file.
+func (s *fileStateStore) Update(fn func(state map[string]TrackedAlert)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	unlock, err := lockFile(s.path + ".lock")
+	if err != nil {
+		return fmt.Errorf("lock state file: %w", err)
+	}
+	defer unlock()
+
+	state, err := s.load()
+	if err != nil {
+		return err
+	}
+	fn(state)
+	return s.save(state)
+}
+
+func (s *fileStateStore) load() (map[string]TrackedAlert, error) {
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return map[string]TrackedAlert{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read state file: %w", err)
+	}
+
+	state := map[string]TrackedAlert{}
+	if len(data) == 0 {
+		return state, nil
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("decode state file: %w", err)
+	}
+	return state, nil
+}
+
+func (s *fileStateStore) save(state map[string]TrackedAlert) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("encode state: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("create state file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write state file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write state file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("replace state file: %w", err)
+	}
+	return nil
+}
+
+// stateTracker remembers fingerprints across Alertmanager snapshots so alerts
+// that disappear or pass their endsAt can be reported as resolved.
+type stateTracker struct {
+	mu        sync.Mutex
+	store     StateStore
+	retention time.Duration
+	now       func() time.Time
+}
+
+func newStateTracker(store StateStore, retention time.Duration) *stateTracker {
+	if retention <= 0 {
+		retention = defaultStateRetention
+	}
+	return &stateTracker{
+		store:     store,
+		retention: retention,
+		now:       time.Now,
+	}
+}
+
+// newStateTrackerFromConfig builds the optional tracker from provider config.
+// It returns nil when stateStore is not configured.
+func newStateTrackerFromConfig(config map[string]any) (*stateTracker, error) {
+	kind, _ := config["stateStore"].(string)
+	if kind == "" {
+		return nil, nil
+	}
+
+	retention, err := durationConfig(config, "stateRetention", defaultStateRetention)
+	if err != nil {
+		return nil, err
+	}
+
+	switch kind {
+	case "memory":
+		return newStateTracker(NewMemoryStateStore(), retention), nil
+	case "file":
+		path, ok := config["statePath"].(string)
+		if !ok || path == "" {
+			return nil, fmt.Errorf("missing required config field: statePath")
+		}
+		return newStateTracker(NewFileStateStore(path), retention), nil
+	default:
+		return nil, fmt.Errorf("unsupported stateStore: %s", kind)
+	}
+}
+
+// reconcile records a complete, converted Alertmanager snapshot and returns
+// the live alerts followed by the resolved alerts still within the retention
+// window. Only unfiltered snapshots may be passed, otherwise filtered-out
+// alerts would be treated as resolved.
+func (t *stateTracker) reconcile(live []schema.Alert) ([]schema.Alert, error) {
+	var alerts []schema.Alert
+	err := t.update(func(state map[string]TrackedAlert) {
+		now := t.now()
+		seen := make(map[string]bool, len(live))
+		alerts = make([]schema.Alert, 0, len(live))
+
+		for _, alert := range live {
+			rec := trackAlert(alert, now)
+			state[alert.ID] = rec
+			seen[alert.ID] = true
+			alerts = append(alerts, rec.alert())
+		}
+
+		for fp, rec := range state {
+			if seen[fp] {
+				continue
+			}
+			if rec.ResolvedAt.IsZero() {
+				// The alert left Alertmanager since the last snapshot; endsAt is
+				// the best resolution time when it has already passed.
+				rec.ResolvedAt = now
+				if !rec.EndsAt.IsZero() && rec.EndsAt.Before(now) {
+					rec.ResolvedAt = rec.EndsAt
+				}
+				state[fp] = rec
+			}
+			if rec.ResolvedAt.Before(now.Add(-t.retention)) {
+				delete(state, fp)
+				continue
+			}
+			alerts = append(alerts, rec.alert())
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return alerts, nil
+}
+
+// record stores alerts pushed to the provider, such as webhook notifications,
+// without treating absent fingerprints as resolved.
+func (t *stateTracker) record(alerts []schema.Alert) error {
+	return t.update(func(state map[string]TrackedAlert) {
+		now := t.now()
+		for _, alert := range alerts {
+			state[alert.ID] = trackAlert(alert, now)
+		}
+
+		for fp, rec := range state {
+			if !rec.ResolvedAt.IsZero() && rec.ResolvedAt.Before(now.Add(-t.retention)) {
+				delete(state, fp)
+			}
+		}
+	})
+}
+
+// update applies fn to the stored state and saves the result. Stores that
+// implement StateUpdater hold their own lock for the whole cycle.
+func (t *stateTracker) update(fn func(state map[string]TrackedAlert)) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if updater, ok := t.store.(StateUpdater); ok {
+		if err := updater.Update(fn); err != nil {
+			return fmt.Errorf("update alert state: %w", err)
+		}
+		return nil
+	}
+
+	state, err := t.store.Load()
+	if err != nil {
+		return fmt.Errorf("load alert state: %w", err)
+	}
+	fn(state)
+	if err := t.store.Save(state); err != nil {
+		return fmt.Errorf("save alert state: %w", err)
+	}
+	return nil
+}
+
+// trackAlert builds the tracked state for an alert seen at now.
+// Alerts whose endsAt has passed are considered resolved at endsAt.
+func trackAlert(alert schema.Alert, now time.Time) TrackedAlert {
+	rec := TrackedAlert{
+		Alert:    alert,
+		LastSeen: now,
+	}
+	if endsAt, ok := alert.Fields["endsAt"].(time.Time); ok {
+		rec.EndsAt = endsAt
+		if !endsAt.After(now) {
+			rec.ResolvedAt = endsAt
+		}
+	}
+	return rec
+}
+
+// lookup returns a tracked alert by fingerprint.
+func (t *stateTracker) lookup(id string) (schema.Alert, bool, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	state, err := t.store.Load()
+	if err != nil {
+		return schema.Alert{}, false, fmt.Errorf("load alert state: %w", err)
+	}
+	rec, ok := state[id]
+	if !ok {
+		return schema.Alert{}, false, nil
+	}
+	return rec.alert(), true, nil
+}
+
+// alert returns a copy of the tracked alert, marked resolved when a
+// resolution time is known. Fields and Metadata are copied so callers cannot
+// modify the stored state.
+func (rec TrackedAlert) alert() schema.Alert {
+	alert := rec.Alert
+	alert.Fields = cloneMap(alert.Fields)
+	alert.Metadata = cloneMap(alert.Metadata)
+	if rec.ResolvedAt.IsZero() {
+		return alert
+	}
+
+	if alert.Metadata == nil {
+		alert.Metadata = map[string]any{}
+	}
+	alert.Metadata["resolvedAt"] = rec.ResolvedAt
+	alert.Status = "resolved"
+	alert.UpdatedAt = rec.ResolvedAt
+	return alert
+}
+
+// cloneMap returns a shallow copy of m, or nil when m is nil.
+func cloneMap(m map[string]any) map[string]any {
+	if m == nil {
+		return nil
+	}
+	out := make(map[string]any, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
+
+// matchesQuery applies an AlertQuery to an already converted alert. It is used
+// when alerts are filtered client-side instead of by Alertmanager.
+func matchesQuery(alert schema.Alert, query schema.AlertQuery) bool {
+	if !matchesStatus(alert, query.Statuses) {
+		return false
+	}
+
+	if !matchesSeverity(alert, query.Severities) {
+		return false
+	}
+
+	return matchesScope(alertLabels(alert), query.Scope)
+}
+
+// matchesStatus reports whether an alert's status is one of statuses. An empty
+// list matches every alert.
+func matchesStatus(alert schema.Alert, statuses []string) bool {
+	if len(statuses) == 0 {
+		return true
+	}
+	for _, status := range statuses {
+		if normalizeQueryStatus(status) == alert.Status {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesSeverity reports whether an alert's normalized severity is one of severities.
+// An empty list matches every alert.
+func matchesSeverity(alert schema.Alert, severities []string) bool {
+	if len(severities) == 0 {
+		return true
+	}
+	for _, severity := range severities {
+		if severity == alert.Severity {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesScope reports whether labels carry the service, team and env values of a QueryScope.
+func matchesScope(labels map[string]string, scope schema.QueryScope) bool {
+	for label, value := range scopeLabels(scope) {
+		if labels[label] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// scopeLabels returns the label matchers implied by a QueryScope.
+func scopeLabels(scope schema.QueryScope) map[string]string {
+	labels := map[string]string{}
+	if scope.Service != "" {
+		labels["service"] = scope.Service
+	}
+	if scope.Team != "" {
+		labels["team"] = scope.Team
+	}
+	if scope.Environment != "" {
+		labels["env"] = scope.Environment
+	}
+	return labels
+}
+
+// normalizeQueryStatus maps an OpsOrch query status to the status reported on converted alerts.
+func normalizeQueryStatus(status string) string {
+	switch status {
+	case "firing", "open", "active":
+		return "firing"
+	case "resolved", "closed":
+		return "resolved"
+	default:
+		return status
+	}
+}
+
+// alertLabels returns the labels stored in an alert's Fields, which may have
+// been round-tripped through JSON by a StateStore.
+func alertLabels(alert schema.Alert) map[string]string {
+	return stringMapField(alert, "labels")
+}
+
+// stringMapField returns a string map stored in alert.Fields, which may have
+// been decoded from JSON as map[string]any.
+func stringMapField(alert schema.Alert, key string) map[string]string {
+	switch values := alert.Fields[key].(type) {
+	case map[string]string:
+		return values
+	case map[string]any:
+		out := make(map[string]string, len(values))
+		for k, v := range values {
+			if s, ok := v.(string); ok {
+				out[k] = s
+			}
+		}
+		return out
+	default:
+		return map[string]string{}
+	}
+}
@@ -0,0 +1,262 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/opsorch/opsorch-core/schema"
+)
+
+func TestStateTrackerReconcile(t *testing.T) {
+	now := time.Date(2025, 12, 3, 12, 0, 0, 0, time.UTC)
+	tracker := newStateTracker(NewMemoryStateStore(), time.Hour)
+	tracker.now = func() time.Time { return now }
+
+	prov := &PrometheusAlertProvider{}
+	first := []schema.Alert{
+		prov.convertAlertmanagerAlert(testAlertmanagerAlert("gone", "2025-12-03T13:00:00Z")),
+		prov.convertAlertmanagerAlert(testAlertmanagerAlert("expired", "2025-12-03T11:30:00Z")),
+		prov.convertAlertmanagerAlert(testAlertmanagerAlert("live", "2025-12-03T13:00:00Z")),
+	}
+	alerts, err := tracker.reconcile(first)
+	if err != nil {
+		t.Fatalf("reconcile() error = %v", err)
+	}
+	statuses := alertStatuses(alerts)
+	if statuses["expired"] != "resolved" {
+		t.Errorf("alert past endsAt status = %v, want resolved", statuses["expired"])
+	}
+	if statuses["live"] != "firing" {
+		t.Errorf("live alert status = %v, want firing", statuses["live"])
+	}
+
+	now = now.Add(5 * time.Minute)
+	alerts, err = tracker.reconcile(first[2:])
+	if err != nil {
+		t.Fatalf("reconcile() error = %v", err)
+	}
+	if len(alerts) != 3 {
+		t.Fatalf("expected live and 2 resolved alerts, got %d", len(alerts))
+	}
+	for _, alert := range alerts {
+		if alert.ID != "gone" {
+			continue
+		}
+		if alert.Status != "resolved" {
+			t.Errorf("disappeared alert status = %v, want resolved", alert.Status)
+		}
+		if !alert.UpdatedAt.Equal(now) {
+			t.Errorf("disappeared alert resolved at %v, want %v", alert.UpdatedAt, now)
+		}
+	}
+
+	got, ok, err := tracker.lookup("gone")
+	if err != nil || !ok {
+		t.Fatalf("lookup() = %v, %v, want tracked alert", ok, err)
+	}
+	if got.Metadata["resolvedAt"] == nil {
+		t.Error("expected resolvedAt in metadata")
+	}
+
+	now = now.Add(2 * time.Hour)
+	alerts, err = tracker.reconcile(first[2:])
+	if err != nil {
+		t.Fatalf("reconcile() error = %v", err)
+	}
+	if len(alerts) != 1 {
+		t.Errorf("expected resolved alerts past retention to be pruned, got %d alerts", len(alerts))
+	}
+}
+
+func TestFileStateStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	store := NewFileStateStore(path)
+
+	state, err := store.Load()
+	if err != nil {
+		t.Fatalf("Load() on missing file error = %v", err)
+	}
+	if len(state) != 0 {
+		t.Fatalf("expected empty state, got %d entries", len(state))
+	}
+
+	resolvedAt := time.Date(2025, 12, 3, 12, 0, 0, 0, time.UTC)
+	state["abc123"] = TrackedAlert{
+		Alert:      schema.Alert{ID: "abc123", Title: "HighCPU"},
+		ResolvedAt: resolvedAt,
+	}
+	if err := store.Save(state); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded, err := NewFileStateStore(path).Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if loaded["abc123"].Alert.Title != "HighCPU" || !loaded["abc123"].ResolvedAt.Equal(resolvedAt) {
+		t.Errorf("loaded state = %+v, want saved alert", loaded["abc123"])
+	}
+}
+
+func TestFileStateStoreSharedUpdates(t *testing.T) {
+	// Two stores on one path stand in for the alert plugin and the webhook
+	// receiver; the lock file keeps their updates from overwriting each other.
+	path := filepath.Join(t.TempDir(), "state.json")
+	trackers := []*stateTracker{
+		newStateTracker(NewFileStateStore(path), time.Hour),
+		newStateTracker(NewFileStateStore(path), time.Hour),
+	}
+
+	var wg sync.WaitGroup
+	for i, tracker := range trackers {
+		for j := 0; j < 20; j++ {
+			wg.Add(1)
+			go func(tracker *stateTracker, id string) {
+				defer wg.Done()
+				if err := tracker.record([]schema.Alert{{ID: id}}); err != nil {
+					t.Errorf("record() error = %v", err)
+				}
+			}(tracker, fmt.Sprintf("%d-%d", i, j))
+		}
+	}
+	wg.Wait()
+
+	state, err := NewFileStateStore(path).Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(state) != 40 {
+		t.Errorf("stored %d alerts, want 40", len(state))
+	}
+}
+
+func TestQueryWithStateTracker(t *testing.T) {
+	served := []alertmanagerAlert{
+		testAlertmanagerAlert("abc123", "2099-01-01T00:00:00Z"),
+		testAlertmanagerAlert("def456", "2099-01-01T00:00:00Z"),
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v2/alerts" {
+			if r.URL.RawQuery != "" {
+				t.Errorf("expected unfiltered snapshot, got query %q", r.URL.RawQuery)
+			}
+			json.NewEncoder(w).Encode(served)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	prov := &PrometheusAlertProvider{
+		baseURL: server.URL,
+		client:  &http.Client{},
+		tracker: newStateTracker(NewMemoryStateStore(), time.Hour),
+	}
+
+	if _, err := prov.Query(context.Background(), schema.AlertQuery{}); err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+
+	served = served[1:]
+	alerts, err := prov.Query(context.Background(), schema.AlertQuery{Statuses: []string{"resolved"}})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(alerts) != 1 || alerts[0].ID != "abc123" {
+		t.Fatalf("resolved alerts = %+v, want abc123", alerts)
+	}
+
+	alert, err := prov.Get(context.Background(), "abc123")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if alert.Status != "resolved" {
+		t.Errorf("Status = %v, want resolved", alert.Status)
+	}
+}
+
+func TestNewStateTrackerFromConfig(t *testing.T) {
+	tracker, err := newStateTrackerFromConfig(map[string]any{})
+	if err != nil || tracker != nil {
+		t.Fatalf("expected no tracker without stateStore, got %v, %v", tracker, err)
+	}
+
+	if _, err := newStateTrackerFromConfig(map[string]any{"stateStore": "file"}); err == nil {
+		t.Error("expected error when statePath missing")
+	}
+
+	tracker, err = newStateTrackerFromConfig(map[string]any{"stateStore": "memory", "stateRetention": "2h"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tracker.retention != 2*time.Hour {
+		t.Errorf("retention = %v, want 2h", tracker.retention)
+	}
+}
+
+func testAlertmanagerAlert(fingerprint, endsAt string) alertmanagerAlert {
+	amAlert := alertmanagerAlert{
+		Fingerprint: fingerprint,
+		Labels:      map[string]string{"alertname": "Alert-" + fingerprint, "severity": "warning"},
+		Annotations: map[string]string{},
+		StartsAt:    "2025-12-03T10:00:00Z",
+		EndsAt:      endsAt,
+		UpdatedAt:   "2025-12-03T10:05:00Z",
+	}
+	amAlert.Status.State = "active"
+	return amAlert
+}
+
+func alertStatuses(alerts []schema.Alert) map[string]string {
+	statuses := make(map[string]string, len(alerts))
+	for _, alert := range alerts {
+		statuses[alert.ID] = alert.Status
+	}
+	return statuses
+}
+
+func TestTrackedStateIsNotAnnotated(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode([]alertmanagerAlert{testAlertmanagerAlert("abc123", "2099-01-01T00:00:00Z")})
+	}))
+	defer server.Close()
+
+	store := NewMemoryStateStore()
+	prov := &PrometheusAlertProvider{
+		baseURL: server.URL,
+		client:  &http.Client{},
+		tracker: newStateTracker(store, time.Hour),
+		flaps:   newFlapDetector(time.Hour, 3),
+	}
+
+	// Concurrent queries must not write into maps shared with the store.
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := prov.Query(context.Background(), schema.AlertQuery{}); err != nil {
+				t.Errorf("Query() error = %v", err)
+			}
+			if _, err := store.Load(); err != nil {
+				t.Errorf("Load() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	state, err := store.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if _, ok := state["abc123"].Alert.Fields["flapping"]; ok {
+		t.Errorf("stored fields = %v, want no flapping annotation", state["abc123"].Alert.Fields)
+	}
+}
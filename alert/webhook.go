@@ -0,0 +1,191 @@
+package alert
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/opsorch/opsorch-core/schema"
+	"github.com/prometheus/common/model"
+)
+
+// maxWebhookBodyBytes bounds the size of a single webhook delivery.
+const maxWebhookBodyBytes = 4 << 20
+
+// webhookMessage is the Alertmanager webhook payload (version 4).
+type webhookMessage struct {
+	Version           string            `json:"version"`
+	GroupKey          string            `json:"groupKey"`
+	TruncatedAlerts   int               `json:"truncatedAlerts"`
+	Status            string            `json:"status"`
+	Receiver          string            `json:"receiver"`
+	GroupLabels       map[string]string `json:"groupLabels"`
+	CommonLabels      map[string]string `json:"commonLabels"`
+	CommonAnnotations map[string]string `json:"commonAnnotations"`
+	ExternalURL       string            `json:"externalURL"`
+	Alerts            []webhookAlert    `json:"alerts"`
+}
+
+// webhookAlert is a single alert within a webhook payload.
+type webhookAlert struct {
+	Status       string            `json:"status"` // firing or resolved
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+	StartsAt     string            `json:"startsAt"`
+	EndsAt       string            `json:"endsAt"`
+	GeneratorURL string            `json:"generatorURL"`
+	Fingerprint  string            `json:"fingerprint"`
+}
+
+// webhookHandler ingests Alertmanager webhook notifications into the provider's state tracker.
+type webhookHandler struct {
+	provider *PrometheusAlertProvider
+	secret   string
+	username string
+	password string
+	now      func() time.Time
+}
+
+// NewWebhookHandler creates an http.Handler that accepts Alertmanager webhook
+// notifications and stores them so Query and Get can serve them. The config
+// is the alert provider config and must enable the file stateStore, since the
+// handler's own provider is the only reader of a memory store.
+func NewWebhookHandler(config map[string]any) (http.Handler, error) {
+	if kind, _ := config["stateStore"].(string); kind != "file" {
+		return nil, fmt.Errorf("webhook receiver requires stateStore: file to share alerts with the alert plugin")
+	}
+	prov, err := NewPrometheusAlertProvider(config)
+	if err != nil {
+		return nil, err
+	}
+	return prov.(*PrometheusAlertProvider).WebhookHandler(config)
+}
+
+// WebhookHandler returns an http.Handler that records webhook notifications
+// in this provider's state tracker. The webhookSecret (bearer token) or
+// webhookUsername/webhookPassword (basic auth) config fields protect the
+// endpoint; one of them is required unless webhookAllowUnauthenticated is set.
+func (p *PrometheusAlertProvider) WebhookHandler(config map[string]any) (http.Handler, error) {
+	if p.tracker == nil {
+		return nil, fmt.Errorf("webhook receiver requires stateStore to be configured")
+	}
+
+	h := &webhookHandler{provider: p, now: time.Now}
+	h.secret, _ = config["webhookSecret"].(string)
+	h.username, _ = config["webhookUsername"].(string)
+	h.password, _ = config["webhookPassword"].(string)
+	if (h.username == "") != (h.password == "") {
+		return nil, fmt.Errorf("webhookUsername and webhookPassword must be set together")
+	}
+	if allow, _ := config["webhookAllowUnauthenticated"].(bool); h.secret == "" && h.username == "" && !allow {
+		return nil, fmt.Errorf("webhook receiver requires webhookSecret or webhookUsername, or webhookAllowUnauthenticated: true")
+	}
+
+	return h, nil
+}
+
+func (h *webhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !h.authorized(r) {
+		w.Header().Set("WWW-Authenticate", `Basic realm="opsorch"`)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var msg webhookMessage
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxWebhookBodyBytes)).Decode(&msg); err != nil {
+		http.Error(w, fmt.Sprintf("decode webhook: %v", err), http.StatusBadRequest)
+		return
+	}
+	if msg.Version != "4" {
+		http.Error(w, fmt.Sprintf("unsupported webhook version: %s", msg.Version), http.StatusBadRequest)
+		return
+	}
+
+	now := h.now()
+	alerts := make([]schema.Alert, 0, len(msg.Alerts))
+	for _, alert := range msg.Alerts {
+		alerts = append(alerts, h.provider.convertAlertmanagerAlert(convertWebhookAlert(alert, msg.Receiver, now)))
+	}
+	h.provider.observeFlapping(alerts)
+
+	if err := h.provider.tracker.record(alerts); err != nil {
+		http.Error(w, fmt.Sprintf("store alerts: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// authorized checks the bearer secret or basic auth credentials. Without
+// either, the endpoint was explicitly opened with webhookAllowUnauthenticated.
+func (h *webhookHandler) authorized(r *http.Request) bool {
+	if h.secret == "" && h.username == "" {
+		return true
+	}
+
+	if h.secret != "" {
+		if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok &&
+			subtle.ConstantTimeCompare([]byte(token), []byte(h.secret)) == 1 {
+			return true
+		}
+	}
+
+	if h.username != "" {
+		if user, pass, ok := r.BasicAuth(); ok &&
+			subtle.ConstantTimeCompare([]byte(user), []byte(h.username)) == 1 &&
+			subtle.ConstantTimeCompare([]byte(pass), []byte(h.password)) == 1 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// convertWebhookAlert maps a webhook alert onto the Alertmanager API model so
+// it goes through the same conversion as polled alerts.
+func convertWebhookAlert(alert webhookAlert, receiver string, now time.Time) alertmanagerAlert {
+	amAlert := alertmanagerAlert{
+		Fingerprint:  alert.Fingerprint,
+		Labels:       alert.Labels,
+		Annotations:  alert.Annotations,
+		StartsAt:     alert.StartsAt,
+		EndsAt:       alert.EndsAt,
+		UpdatedAt:    now.UTC().Format(time.RFC3339),
+		GeneratorURL: alert.GeneratorURL,
+	}
+	if receiver != "" {
+		amAlert.Receivers = []alertmanagerReceiver{{Name: receiver}}
+	}
+	if amAlert.Fingerprint == "" {
+		amAlert.Fingerprint = labelsFingerprint(alert.Labels)
+	}
+
+	amAlert.Status.State = "active"
+	if alert.Status == "resolved" {
+		// Resolved notifications carry the resolution time in endsAt; fall
+		// back to the delivery time if it is missing or in the future.
+		if endsAt, err := time.Parse(time.RFC3339, alert.EndsAt); err != nil || endsAt.After(now) {
+			amAlert.EndsAt = amAlert.UpdatedAt
+		}
+	}
+
+	return amAlert
+}
+
+// labelsFingerprint computes the Alertmanager fingerprint of a label set.
+func labelsFingerprint(labels map[string]string) string {
+	set := make(model.LabelSet, len(labels))
+	for k, v := range labels {
+		set[model.LabelName(k)] = model.LabelValue(v)
+	}
+	return set.Fingerprint().String()
+}
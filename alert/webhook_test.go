@@ -0,0 +1,170 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/opsorch/opsorch-core/schema"
+)
+
+const testWebhookPayload = `{
+	"version": "4",
+	"status": "resolved",
+	"receiver": "opsorch",
+	"alerts": [
+		{
+			"status": "resolved",
+			"labels": {"alertname": "ShortLived", "severity": "warning", "service": "api"},
+			"annotations": {"description": "Blipped for a few seconds"},
+			"startsAt": "2025-12-03T10:00:00Z",
+			"endsAt": "2025-12-03T10:00:30Z",
+			"generatorURL": "http://prometheus:9090/graph",
+			"fingerprint": "short123"
+		},
+		{
+			"status": "firing",
+			"labels": {"alertname": "StillFiring"},
+			"annotations": {},
+			"startsAt": "2025-12-03T10:00:00Z",
+			"endsAt": "0001-01-01T00:00:00Z"
+		}
+	]
+}`
+
+func TestWebhookHandler(t *testing.T) {
+	prov := &PrometheusAlertProvider{
+		baseURL: "http://alertmanager:9093",
+		client:  &http.Client{},
+		tracker: newStateTracker(NewMemoryStateStore(), time.Hour),
+	}
+	prov.tracker.now = func() time.Time { return time.Date(2025, 12, 3, 10, 1, 0, 0, time.UTC) }
+
+	handler, err := prov.WebhookHandler(map[string]any{"webhookSecret": "s3cret"})
+	if err != nil {
+		t.Fatalf("WebhookHandler() error = %v", err)
+	}
+
+	t.Run("rejects missing secret", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("POST", "/webhook", strings.NewReader(testWebhookPayload)))
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("status = %d, want 401", rec.Code)
+		}
+	})
+
+	t.Run("rejects unsupported version", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/webhook", strings.NewReader(`{"version":"3","alerts":[]}`))
+		req.Header.Set("Authorization", "Bearer s3cret")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("status = %d, want 400", rec.Code)
+		}
+	})
+
+	t.Run("stores alerts", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/webhook", strings.NewReader(testWebhookPayload))
+		req.Header.Set("Authorization", "Bearer s3cret")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body.String())
+		}
+
+		alert, ok, err := prov.tracker.lookup("short123")
+		if err != nil || !ok {
+			t.Fatalf("lookup() = %v, %v, want stored alert", ok, err)
+		}
+		if alert.Status != "resolved" {
+			t.Errorf("Status = %v, want resolved", alert.Status)
+		}
+		if alert.Title != "ShortLived" {
+			t.Errorf("Title = %v, want ShortLived", alert.Title)
+		}
+
+		fp := labelsFingerprint(map[string]string{"alertname": "StillFiring"})
+		alert, ok, err = prov.tracker.lookup(fp)
+		if err != nil || !ok {
+			t.Fatalf("lookup(%s) = %v, %v, want stored alert", fp, ok, err)
+		}
+		if alert.Status != "firing" {
+			t.Errorf("Status = %v, want firing", alert.Status)
+		}
+	})
+}
+
+func TestWebhookAlertsServedByQuery(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v2/alerts" {
+			json.NewEncoder(w).Encode([]alertmanagerAlert{})
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	prov := &PrometheusAlertProvider{
+		baseURL: server.URL,
+		client:  &http.Client{},
+		tracker: newStateTracker(NewMemoryStateStore(), time.Hour),
+	}
+	prov.tracker.now = func() time.Time { return time.Date(2025, 12, 3, 10, 1, 0, 0, time.UTC) }
+	handler, err := prov.WebhookHandler(map[string]any{"webhookAllowUnauthenticated": true})
+	if err != nil {
+		t.Fatalf("WebhookHandler() error = %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("POST", "/webhook", strings.NewReader(testWebhookPayload)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+
+	alerts, err := prov.Query(context.Background(), schema.AlertQuery{
+		Statuses: []string{"resolved"},
+		Scope:    schema.QueryScope{Service: "api"},
+	})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(alerts) != 1 || alerts[0].ID != "short123" {
+		t.Fatalf("alerts = %+v, want short123", alerts)
+	}
+
+	alert, err := prov.Get(context.Background(), "short123")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if alert.Status != "resolved" {
+		t.Errorf("Status = %v, want resolved", alert.Status)
+	}
+}
+
+func TestWebhookHandlerRequiresStateStore(t *testing.T) {
+	prov := &PrometheusAlertProvider{baseURL: "http://alertmanager:9093", client: &http.Client{}}
+	if _, err := prov.WebhookHandler(map[string]any{"webhookSecret": "s3cret"}); err == nil {
+		t.Fatal("expected error without state tracker")
+	}
+	if _, err := NewWebhookHandler(map[string]any{"alertmanagerURL": "http://alertmanager:9093", "stateStore": "memory", "webhookSecret": "s3cret"}); err == nil {
+		t.Error("expected error for a memory store in a standalone receiver")
+	}
+}
+
+func TestWebhookHandlerRequiresAuth(t *testing.T) {
+	prov := &PrometheusAlertProvider{
+		baseURL: "http://alertmanager:9093",
+		client:  &http.Client{},
+		tracker: newStateTracker(NewMemoryStateStore(), time.Hour),
+	}
+	if _, err := prov.WebhookHandler(map[string]any{}); err == nil {
+		t.Error("expected error without webhook credentials")
+	}
+	if _, err := prov.WebhookHandler(map[string]any{"webhookAllowUnauthenticated": true}); err != nil {
+		t.Errorf("WebhookHandler() with explicit opt-out error = %v", err)
+	}
+}
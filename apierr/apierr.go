@@ -0,0 +1,187 @@
+// Package apierr defines the typed errors returned by the metric and alert
+// providers, so callers can tell a bad query from an unreachable backend or a
+// missing alert without parsing error messages.
+package apierr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+)
+
+// Code classifies an error.
+type Code string
+
+// Error codes.
+const (
+	// InvalidArgument means the request or config was malformed.
+	InvalidArgument Code = "invalid_argument"
+	// NotFound means the requested object does not exist.
+	NotFound Code = "not_found"
+	// Unauthorized means the backend rejected the credentials or the
+	// operation is not permitted.
+	Unauthorized Code = "unauthorized"
+	// Unavailable means the backend could not be reached or failed; the
+	// request may succeed if retried.
+	Unavailable Code = "unavailable"
+	// Timeout means the request ran out of time.
+	Timeout Code = "timeout"
+	// Canceled means the caller canceled the request.
+	Canceled Code = "canceled"
+	// BadQuery means the backend rejected or could not execute the query.
+	BadQuery Code = "bad_query"
+	// PartialResult means the result is usable but incomplete. Providers
+	// return it alongside the result.
+	PartialResult Code = "partial_result"
+	// Internal is any error that was not classified.
+	Internal Code = "internal"
+)
+
+// Error is an error with a Code and optional structured details. Its message
+// is the message of the wrapped error.
+type Error struct {
+	Code    Code
+	Details map[string]any
+	Err     error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// New returns an error with the given code and a formatted message.
+func New(code Code, format string, args ...any) error {
+	return &Error{Code: code, Err: fmt.Errorf(format, args...)}
+}
+
+// Wrap attaches a code and details to err. It returns nil if err is nil.
+func Wrap(code Code, err error, details map[string]any) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Code: code, Details: details, Err: err}
+}
+
+// CodeOf returns the code of the first *Error in err's chain. Unclassified
+// context errors map to Timeout or Canceled and anything else to Internal. It
+// returns "" for a nil error.
+func CodeOf(err error) Code {
+	if err == nil {
+		return ""
+	}
+	var typed *Error
+	if errors.As(err, &typed) {
+		return typed.Code
+	}
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return Timeout
+	case errors.Is(err, context.Canceled):
+		return Canceled
+	default:
+		return Internal
+	}
+}
+
+// DetailsOf returns the details of the first *Error in err's chain.
+func DetailsOf(err error) map[string]any {
+	var typed *Error
+	if errors.As(err, &typed) {
+		return typed.Details
+	}
+	return nil
+}
+
+// FromStatus maps an HTTP status code from a backend to an error code.
+func FromStatus(status int) Code {
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return Unauthorized
+	case status == http.StatusNotFound:
+		return NotFound
+	case status == http.StatusRequestTimeout || status == http.StatusGatewayTimeout:
+		return Timeout
+	case status == http.StatusUnprocessableEntity:
+		return BadQuery
+	case status == http.StatusTooManyRequests || status >= 500:
+		return Unavailable
+	case status >= 400:
+		return InvalidArgument
+	default:
+		return Internal
+	}
+}
+
+// Classify attaches a code to an error from the Prometheus API client or an
+// HTTP transport, based on its v1.Error type, the HTTP status behind it, or
+// the transport failure. Errors that are already typed, and nil, are returned
+// unchanged.
+func Classify(err error) error {
+	if err == nil {
+		return nil
+	}
+	var typed *Error
+	if errors.As(err, &typed) {
+		return err
+	}
+	if code := CodeOf(err); code == Timeout || code == Canceled {
+		return Wrap(code, err, nil)
+	}
+
+	var promErr *v1.Error
+	if errors.As(err, &promErr) {
+		details := map[string]any{"type": string(promErr.Type)}
+		if promErr.Detail != "" {
+			details["detail"] = promErr.Detail
+		}
+		return Wrap(prometheusCode(promErr), err, details)
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return Wrap(Timeout, err, nil)
+	}
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return Wrap(Unavailable, err, nil)
+	}
+	return err
+}
+
+func prometheusCode(err *v1.Error) Code {
+	switch err.Type {
+	case v1.ErrBadData, v1.ErrExec:
+		return BadQuery
+	case v1.ErrTimeout:
+		return Timeout
+	case v1.ErrCanceled:
+		return Canceled
+	case v1.ErrClient, v1.ErrServer:
+		// The client reports non-JSON error responses as "client error: 401"
+		// or "server error: 502".
+		var kind string
+		var status int
+		if _, scanErr := fmt.Sscanf(err.Msg, "%s error: %d", &kind, &status); scanErr == nil {
+			return FromStatus(status)
+		}
+		if err.Type == v1.ErrClient {
+			return InvalidArgument
+		}
+		return Unavailable
+	case v1.ErrBadResponse, "unavailable":
+		return Unavailable
+	case "not_found":
+		return NotFound
+	default:
+		return Internal
+	}
+}
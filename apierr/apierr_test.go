@@ -0,0 +1,91 @@
+package apierr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"testing"
+
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+)
+
+func TestCodeOf(t *testing.T) {
+	tests := []struct {
+		err  error
+		want Code
+	}{
+		{nil, ""},
+		{New(NotFound, "alert not found: %s", "x"), NotFound},
+		{fmt.Errorf("get alert: %w", New(NotFound, "alert not found")), NotFound},
+		{fmt.Errorf("query: %w", context.DeadlineExceeded), Timeout},
+		{context.Canceled, Canceled},
+		{errors.New("boom"), Internal},
+	}
+	for _, tt := range tests {
+		if got := CodeOf(tt.err); got != tt.want {
+			t.Errorf("CodeOf(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestWrapKeepsMessage(t *testing.T) {
+	err := fmt.Errorf("query alerts: %w", Wrap(Unavailable, errors.New("connection refused"), map[string]any{"status": 503}))
+	if err.Error() != "query alerts: connection refused" {
+		t.Errorf("Error() = %q", err.Error())
+	}
+	if DetailsOf(err)["status"] != 503 {
+		t.Errorf("DetailsOf() = %v", DetailsOf(err))
+	}
+	if Wrap(Internal, nil, nil) != nil {
+		t.Error("Wrap(nil) should be nil")
+	}
+}
+
+func TestFromStatus(t *testing.T) {
+	tests := map[int]Code{
+		400: InvalidArgument,
+		401: Unauthorized,
+		403: Unauthorized,
+		404: NotFound,
+		422: BadQuery,
+		429: Unavailable,
+		502: Unavailable,
+		504: Timeout,
+	}
+	for status, want := range tests {
+		if got := FromStatus(status); got != want {
+			t.Errorf("FromStatus(%d) = %q, want %q", status, got, want)
+		}
+	}
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want Code
+	}{
+		{"bad data", &v1.Error{Type: v1.ErrBadData, Msg: "parse error"}, BadQuery},
+		{"execution", &v1.Error{Type: v1.ErrExec, Msg: "too many samples"}, BadQuery},
+		{"timeout", &v1.Error{Type: v1.ErrTimeout, Msg: "query timed out"}, Timeout},
+		{"unauthorized", &v1.Error{Type: v1.ErrClient, Msg: "client error: 401"}, Unauthorized},
+		{"server error", &v1.Error{Type: v1.ErrServer, Msg: "server error: 502"}, Unavailable},
+		{"bad response", &v1.Error{Type: v1.ErrBadResponse, Msg: "invalid character"}, Unavailable},
+		{"unreachable", &url.Error{Op: "Post", URL: "http://prom", Err: errors.New("connection refused")}, Unavailable},
+		{"deadline", &url.Error{Op: "Post", URL: "http://prom", Err: context.DeadlineExceeded}, Timeout},
+		{"typed", New(NotFound, "missing"), NotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CodeOf(Classify(tt.err)); got != tt.want {
+				t.Errorf("code = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	err := Classify(&v1.Error{Type: v1.ErrClient, Msg: "client error: 403", Detail: "forbidden"})
+	if details := DetailsOf(err); details["type"] != "client_error" || details["detail"] != "forbidden" {
+		t.Errorf("details = %v", details)
+	}
+}
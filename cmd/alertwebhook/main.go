@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/opsorch/opsorch-prometheus-adapter/alert"
+	"github.com/opsorch/opsorch-prometheus-adapter/logging"
+)
+
+func main() {
+	listen := flag.String("listen", ":9097", "address to accept Alertmanager webhook deliveries on")
+	path := flag.String("path", "/webhook", "HTTP path of the webhook endpoint")
+	flag.Parse()
+
+	level, err := logging.LevelFromEnv()
+	logger := logging.NewLogger(os.Stderr, level).With("plugin", "alertwebhook")
+	slog.SetDefault(logger)
+	if err == nil {
+		err = run(*listen, *path)
+	}
+	if err != nil {
+		logger.Error("alertwebhook failed", "error", err)
+		os.Exit(1)
+	}
+}
+
+func run(listen, path string) error {
+	// Share the alert plugin configuration so both processes use the same state store.
+	var cfg map[string]any
+	if err := json.Unmarshal([]byte(os.Getenv("OPSORCH_ALERT_CONFIG")), &cfg); err != nil {
+		return fmt.Errorf("decode OPSORCH_ALERT_CONFIG: %w", err)
+	}
+
+	handler, err := alert.NewWebhookHandler(cfg)
+	if err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(path, handler)
+	srv := &http.Server{
+		Addr:              listen,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.ListenAndServe()
+	}()
+	slog.Info("alertwebhook listening", "listen", listen, "path", path)
+
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	case <-ctx.Done():
+		slog.Info("alertwebhook shutting down")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		return srv.Shutdown(shutdownCtx)
+	}
+}
@@ -0,0 +1,10 @@
+// Command prometheusplugin serves the metric and alert capabilities from one
+// process over the plugin RPC protocol, sharing one HTTP transport between
+// capabilities with the same auth and TLS settings.
+package main
+
+import "github.com/opsorch/opsorch-prometheus-adapter/plugin"
+
+func main() {
+	plugin.Main("prometheusplugin", plugin.Metric, plugin.Alert)
+}
@@ -0,0 +1,141 @@
+// Package httptransport builds the HTTP transport used to reach Prometheus and
+// Alertmanager, applying the auth and TLS settings from a provider config.
+// Providers that are given the same Transport share its connection pool.
+package httptransport
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// Config fields read by New.
+const (
+	KeyUsername              = "username"
+	KeyPassword              = "password"
+	KeyBearerToken           = "bearerToken"
+	KeyTLSCAFile             = "tlsCAFile"
+	KeyTLSCertFile           = "tlsCertFile"
+	KeyTLSKeyFile            = "tlsKeyFile"
+	KeyTLSServerName         = "tlsServerName"
+	KeyTLSInsecureSkipVerify = "tlsInsecureSkipVerify"
+)
+
+var keys = []string{
+	KeyUsername,
+	KeyPassword,
+	KeyBearerToken,
+	KeyTLSCAFile,
+	KeyTLSCertFile,
+	KeyTLSKeyFile,
+	KeyTLSServerName,
+	KeyTLSInsecureSkipVerify,
+}
+
+// Keys returns the config fields read by New.
+func Keys() []string {
+	return append([]string(nil), keys...)
+}
+
+// Select returns the subset of config read by New.
+func Select(config map[string]any) map[string]any {
+	selected := map[string]any{}
+	for _, key := range keys {
+		if value, ok := config[key]; ok {
+			selected[key] = value
+		}
+	}
+	return selected
+}
+
+// Transport is an http.RoundTripper that adds the configured credentials to
+// every request.
+type Transport struct {
+	base        *http.Transport
+	username    string
+	password    string
+	bearerToken string
+}
+
+// New builds a transport from the auth and TLS fields in config. username and
+// password enable basic auth and bearerToken sends an Authorization header;
+// they are mutually exclusive. tlsCAFile, tlsCertFile and tlsKeyFile name PEM
+// files for the server CA and a client certificate.
+func New(config map[string]any) (*Transport, error) {
+	t := &Transport{base: http.DefaultTransport.(*http.Transport).Clone()}
+	t.username, _ = config[KeyUsername].(string)
+	t.password, _ = config[KeyPassword].(string)
+	t.bearerToken, _ = config[KeyBearerToken].(string)
+	if t.bearerToken != "" && (t.username != "" || t.password != "") {
+		return nil, fmt.Errorf("%s and %s/%s are mutually exclusive", KeyBearerToken, KeyUsername, KeyPassword)
+	}
+
+	tlsConfig, err := tlsConfigFrom(config)
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig != nil {
+		t.base.TLSClientConfig = tlsConfig
+	}
+	return t, nil
+}
+
+func tlsConfigFrom(config map[string]any) (*tls.Config, error) {
+	caFile, _ := config[KeyTLSCAFile].(string)
+	certFile, _ := config[KeyTLSCertFile].(string)
+	keyFile, _ := config[KeyTLSKeyFile].(string)
+	serverName, _ := config[KeyTLSServerName].(string)
+	insecure, _ := config[KeyTLSInsecureSkipVerify].(bool)
+	if caFile == "" && certFile == "" && keyFile == "" && serverName == "" && !insecure {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{
+		ServerName:         serverName,
+		InsecureSkipVerify: insecure,
+		MinVersion:         tls.VersionTLS12,
+	}
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", KeyTLSCAFile, err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("invalid %s: no PEM certificates in %s", KeyTLSCAFile, caFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if certFile != "" || keyFile != "" {
+		if certFile == "" || keyFile == "" {
+			return nil, fmt.Errorf("%s and %s must be set together", KeyTLSCertFile, KeyTLSKeyFile)
+		}
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+// RoundTrip sends req with the configured credentials, unless it already
+// carries an Authorization header.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("Authorization") == "" && (t.username != "" || t.password != "" || t.bearerToken != "") {
+		req = req.Clone(req.Context())
+		if t.bearerToken != "" {
+			req.Header.Set("Authorization", "Bearer "+t.bearerToken)
+		} else {
+			req.SetBasicAuth(t.username, t.password)
+		}
+	}
+	return t.base.RoundTrip(req)
+}
+
+// CloseIdleConnections closes the transport's idle connections.
+func (t *Transport) CloseIdleConnections() {
+	t.base.CloseIdleConnections()
+}
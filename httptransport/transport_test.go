@@ -0,0 +1,91 @@
+package httptransport
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	dir := t.TempDir()
+	notPEM := filepath.Join(dir, "ca.pem")
+	if err := os.WriteFile(notPEM, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		config  map[string]any
+		wantErr bool
+	}{
+		{"empty", map[string]any{}, false},
+		{"basic auth", map[string]any{"username": "u", "password": "p"}, false},
+		{"insecure", map[string]any{"tlsInsecureSkipVerify": true}, false},
+		{"bearer and basic", map[string]any{"username": "u", "bearerToken": "t"}, true},
+		{"missing CA file", map[string]any{"tlsCAFile": filepath.Join(dir, "missing.pem")}, true},
+		{"invalid CA file", map[string]any{"tlsCAFile": notPEM}, true},
+		{"cert without key", map[string]any{"tlsCertFile": notPEM}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRoundTripAuth(t *testing.T) {
+	var got string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("Authorization")
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name   string
+		config map[string]any
+		header string
+		want   string
+	}{
+		{"none", map[string]any{}, "", ""},
+		{"basic", map[string]any{"username": "user", "password": "pass"}, "", "Basic dXNlcjpwYXNz"},
+		{"bearer", map[string]any{"bearerToken": "token"}, "", "Bearer token"},
+		{"explicit header wins", map[string]any{"bearerToken": "token"}, "Bearer other", "Bearer other"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport, err := New(tt.config)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			defer transport.CloseIdleConnections()
+
+			req, _ := http.NewRequest("GET", server.URL, nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			resp, err := (&http.Client{Transport: transport}).Do(req)
+			if err != nil {
+				t.Fatalf("Do() error = %v", err)
+			}
+			resp.Body.Close()
+			if got != tt.want {
+				t.Errorf("Authorization = %q, want %q", got, tt.want)
+			}
+			if tt.header == "" && req.Header.Get("Authorization") != "" {
+				t.Error("RoundTrip modified the caller's request")
+			}
+		})
+	}
+}
+
+func TestSelect(t *testing.T) {
+	selected := Select(map[string]any{"url": "http://prom", "username": "u", "tlsInsecureSkipVerify": true})
+	if len(selected) != 2 || selected["username"] != "u" || selected["tlsInsecureSkipVerify"] != true {
+		t.Errorf("Select() = %v", selected)
+	}
+}
@@ -0,0 +1,204 @@
+// Package logging configures the structured log/slog loggers used by the
+// providers and plugin binaries, and redacts credentials from log output.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// KeyLevel is the provider config field that sets the provider's log level.
+const KeyLevel = "logLevel"
+
+// LevelEnv sets the log level of the plugin binaries.
+const LevelEnv = "OPSORCH_PLUGIN_LOG_LEVEL"
+
+// redacted replaces credential values in log output.
+const redacted = "[REDACTED]"
+
+// sensitiveKeys are lower-cased attribute and config keys whose values are
+// never logged.
+var sensitiveKeys = map[string]bool{
+	"password":        true,
+	"bearertoken":     true,
+	"token":           true,
+	"secret":          true,
+	"authorization":   true,
+	"webhookpassword": true,
+	"webhooksecret":   true,
+}
+
+// ParseLevel parses debug, info, warn or error, case-insensitively.
+func ParseLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(s)); err != nil {
+		return 0, fmt.Errorf("invalid log level %q: expected debug, info, warn or error", s)
+	}
+	return level, nil
+}
+
+// LevelFromEnv returns the level set in LevelEnv, or info.
+func LevelFromEnv() (slog.Level, error) {
+	value := os.Getenv(LevelEnv)
+	if value == "" {
+		return slog.LevelInfo, nil
+	}
+	level, err := ParseLevel(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", LevelEnv, err)
+	}
+	return level, nil
+}
+
+// NewLogger returns a logger that writes JSON records at level and above to
+// w, with credentials redacted. Loggers derived from it with WithLevel may
+// lower the level.
+func NewLogger(w io.Writer, level slog.Level) *slog.Logger {
+	handler := slog.NewJSONHandler(w, &slog.HandlerOptions{
+		Level:       slog.LevelDebug,
+		ReplaceAttr: redactAttr,
+	})
+	return slog.New(&levelHandler{level: level, inner: handler})
+}
+
+// FromConfig returns slog.Default(), or a copy at the level set in config's
+// logLevel field. Under a logger from NewLogger the level may be lower than
+// the process level; under any other handler it can only be raised.
+func FromConfig(config map[string]any) (*slog.Logger, error) {
+	value, _ := config[KeyLevel].(string)
+	if value == "" {
+		return slog.Default(), nil
+	}
+	level, err := ParseLevel(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", KeyLevel, err)
+	}
+	return WithLevel(slog.Default(), level), nil
+}
+
+// WithLevel returns a copy of logger that drops records below level.
+func WithLevel(logger *slog.Logger, level slog.Level) *slog.Logger {
+	if h, ok := logger.Handler().(*levelHandler); ok {
+		return slog.New(&levelHandler{level: level, inner: h.inner})
+	}
+	return slog.New(&levelHandler{level: level, inner: logger.Handler()})
+}
+
+// contextKey keys the attributes attached with WithContext.
+type contextKey struct{}
+
+// WithContext returns a copy of ctx carrying attrs, such as a request ID, that
+// loggers from NewLogger add to every record logged with the context.
+func WithContext(ctx context.Context, attrs ...slog.Attr) context.Context {
+	existing, _ := ctx.Value(contextKey{}).([]slog.Attr)
+	merged := make([]slog.Attr, 0, len(existing)+len(attrs))
+	merged = append(append(merged, existing...), attrs...)
+	return context.WithValue(ctx, contextKey{}, merged)
+}
+
+// levelHandler drops records below its level before passing them on, adding
+// the attributes attached to the context with WithContext.
+type levelHandler struct {
+	level slog.Level
+	inner slog.Handler
+}
+
+func (h *levelHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.level && h.inner.Enabled(ctx, level)
+}
+
+func (h *levelHandler) Handle(ctx context.Context, record slog.Record) error {
+	if attrs, ok := ctx.Value(contextKey{}).([]slog.Attr); ok {
+		record = record.Clone()
+		record.AddAttrs(attrs...)
+	}
+	return h.inner.Handle(ctx, record)
+}
+
+func (h *levelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &levelHandler{level: h.level, inner: h.inner.WithAttrs(attrs)}
+}
+
+func (h *levelHandler) WithGroup(name string) slog.Handler {
+	return &levelHandler{level: h.level, inner: h.inner.WithGroup(name)}
+}
+
+// redactAttr hides the values of credential keys and the passwords in URLs,
+// including URLs inside lists and maps.
+func redactAttr(groups []string, attr slog.Attr) slog.Attr {
+	if sensitiveKeys[strings.ToLower(attr.Key)] {
+		return slog.String(attr.Key, redacted)
+	}
+	switch attr.Value.Kind() {
+	case slog.KindString:
+		return slog.String(attr.Key, RedactURL(attr.Value.String()))
+	case slog.KindAny:
+		switch attr.Value.Any().(type) {
+		case []string, []any, map[string]any:
+			return slog.Any(attr.Key, redactValue(attr.Value.Any()))
+		}
+	}
+	return attr
+}
+
+// RedactURL hides the password in a URL's user info, or in each URL of a
+// comma-separated list. Anything that is not such a URL is returned unchanged.
+func RedactURL(raw string) string {
+	if !strings.Contains(raw, "@") {
+		return raw
+	}
+	parts := strings.Split(raw, ",")
+	for i, part := range parts {
+		trimmed := strings.TrimSpace(part)
+		u, err := url.Parse(trimmed)
+		if err != nil || u.User == nil {
+			continue
+		}
+		parts[i] = strings.Replace(part, trimmed, u.Redacted(), 1)
+	}
+	return strings.Join(parts, ",")
+}
+
+// RedactConfig returns a copy of config, including nested sections and lists,
+// with the values of credential fields replaced.
+func RedactConfig(config map[string]any) map[string]any {
+	out := make(map[string]any, len(config))
+	for key, value := range config {
+		if sensitiveKeys[strings.ToLower(key)] {
+			out[key] = redacted
+		} else {
+			out[key] = redactValue(value)
+		}
+	}
+	return out
+}
+
+// redactValue returns value with the passwords in URLs hidden, copying maps
+// and lists rather than modifying them.
+func redactValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		return RedactConfig(v)
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = redactValue(item)
+		}
+		return out
+	case []string:
+		out := make([]string, len(v))
+		for i, item := range v {
+			out[i] = RedactURL(item)
+		}
+		return out
+	case string:
+		return RedactURL(v)
+	default:
+		return value
+	}
+}
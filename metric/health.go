@@ -0,0 +1,69 @@
+package metric
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/opsorch/opsorch-prometheus-adapter/httptransport"
+	"github.com/opsorch/opsorch-prometheus-adapter/logging"
+)
+
+// configKeys are the config fields read by NewPrometheusProvider, besides the
+// transport fields.
+var configKeys = []string{"url", logging.KeyLevel}
+
+// ConfigKeys returns the config fields the metric provider reads, including
+// the auth and TLS fields read by httptransport.
+func ConfigKeys() []string {
+	return append(append([]string(nil), configKeys...), httptransport.Keys()...)
+}
+
+// Health reports whether Prometheus is ready to serve queries and which
+// version it runs.
+type Health struct {
+	URL      string `json:"url"`
+	Ready    bool   `json:"ready"`
+	Version  string `json:"version,omitempty"`
+	Revision string `json:"revision,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Health checks /-/ready and, once Prometheus is ready, reads its version
+// from /api/v1/status/buildinfo. Build info is best effort, since
+// Prometheus-compatible backends do not all serve it.
+func (p *PrometheusProvider) Health(ctx context.Context) Health {
+	health := Health{URL: p.baseURL}
+	if err := p.ready(ctx); err != nil {
+		p.logger.WarnContext(ctx, "prometheus not ready", "url", p.baseURL, "error", err)
+		health.Error = err.Error()
+		return health
+	}
+	health.Ready = true
+
+	if info, err := p.api.Buildinfo(ctx); err == nil {
+		health.Version = info.Version
+		health.Revision = info.Revision
+	}
+	return health
+}
+
+func (p *PrometheusProvider) ready(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", strings.TrimSuffix(p.baseURL, "/")+"/-/ready", nil)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	resp, err := (&http.Client{Transport: p.transport}).Do(req)
+	if err != nil {
+		return fmt.Errorf("execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("prometheus not ready: %d %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
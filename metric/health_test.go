@@ -0,0 +1,46 @@
+package metric
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPrometheusProvider_Health(t *testing.T) {
+	ready := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/-/ready":
+			if !ready {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				w.Write([]byte("Service Unavailable"))
+				return
+			}
+			w.Write([]byte("Prometheus Server is Ready."))
+		case "/api/v1/status/buildinfo":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"status":"success","data":{"version":"2.53.0","revision":"abc"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	provider, err := NewPrometheusProvider(map[string]any{"url": server.URL})
+	if err != nil {
+		t.Fatalf("Failed to create provider: %v", err)
+	}
+
+	health := provider.Health(context.Background())
+	if !health.Ready || health.Version != "2.53.0" || health.Revision != "abc" || health.Error != "" {
+		t.Errorf("Health() = %+v, want ready 2.53.0", health)
+	}
+
+	ready = false
+	health = provider.Health(context.Background())
+	if health.Ready || health.Version != "" || !strings.Contains(health.Error, "503") {
+		t.Errorf("Health() = %+v, want not ready", health)
+	}
+}
@@ -3,9 +3,13 @@ package metric
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"time"
 
 	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-prometheus-adapter/apierr"
+	"github.com/opsorch/opsorch-prometheus-adapter/httptransport"
+	"github.com/opsorch/opsorch-prometheus-adapter/logging"
 	"github.com/prometheus/client_golang/api"
 	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
 	"github.com/prometheus/common/model"
@@ -13,35 +17,76 @@ import (
 
 // PrometheusProvider implements the metric.Provider interface for Prometheus.
 type PrometheusProvider struct {
-	api     v1.API
-	baseURL string
+	api       v1.API
+	baseURL   string
+	transport *httptransport.Transport
+	logger    *slog.Logger
 }
 
-// NewPrometheusProvider creates a new Prometheus provider.
+// NewPrometheusProvider creates a new Prometheus provider with its own
+// transport, built from the auth and TLS fields in config.
 func NewPrometheusProvider(config map[string]any) (*PrometheusProvider, error) {
+	transport, err := httptransport.New(config)
+	if err != nil {
+		return nil, err
+	}
+	return NewPrometheusProviderWithTransport(config, transport)
+}
+
+// NewPrometheusProviderWithTransport creates a Prometheus provider that sends
+// its requests through a shared transport, ignoring any auth and TLS fields
+// in config. A nil transport is replaced by one without auth or TLS settings.
+func NewPrometheusProviderWithTransport(config map[string]any, transport *httptransport.Transport) (*PrometheusProvider, error) {
 	url, ok := config["url"].(string)
 	if !ok || url == "" {
 		return nil, fmt.Errorf("missing required config field: url")
 	}
+	if transport == nil {
+		transport, _ = httptransport.New(nil)
+	}
+	logger, err := logging.FromConfig(config)
+	if err != nil {
+		return nil, err
+	}
 
 	client, err := api.NewClient(api.Config{
-		Address: url,
+		Address:      url,
+		RoundTripper: transport,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create prometheus client: %w", err)
 	}
 
 	return &PrometheusProvider{
-		api:     v1.NewAPI(client),
-		baseURL: url,
+		api:       v1.NewAPI(client),
+		baseURL:   url,
+		transport: transport,
+		logger:    logger,
 	}, nil
 }
 
-// Query executes a metric query against Prometheus.
+// Close closes the idle connections of the provider's transport.
+func (p *PrometheusProvider) Close() error {
+	if p.transport != nil {
+		p.transport.CloseIdleConnections()
+	}
+	return nil
+}
+
+// Query executes a metric query against Prometheus. Errors are classified with
+// apierr codes. Prometheus warnings are logged; use QueryWithWarnings to
+// receive them.
 func (p *PrometheusProvider) Query(ctx context.Context, query schema.MetricQuery) ([]schema.MetricSeries, error) {
+	series, _, err := p.QueryWithWarnings(ctx, query)
+	return series, err
+}
+
+// QueryWithWarnings is Query, also returning the warnings Prometheus reported,
+// which mean the series may be incomplete.
+func (p *PrometheusProvider) QueryWithWarnings(ctx context.Context, query schema.MetricQuery) ([]schema.MetricSeries, []string, error) {
 	promQL, err := buildPromQL(query)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	r := v1.Range{
@@ -50,27 +95,50 @@ func (p *PrometheusProvider) Query(ctx context.Context, query schema.MetricQuery
 		Step:  time.Duration(query.Step) * time.Second,
 	}
 
+	start := time.Now()
 	result, warnings, err := p.api.QueryRange(ctx, promQL, r)
 	if err != nil {
-		return nil, fmt.Errorf("prometheus query failed: %w", err)
+		err = apierr.Classify(err)
+		p.logger.DebugContext(ctx, "prometheus query failed", "promql", promQL, "latency", time.Since(start),
+			"code", apierr.CodeOf(err), "error", err)
+		return nil, nil, fmt.Errorf("prometheus query failed: %w", err)
+	}
+
+	series, err := convertResult(result, promQL, p.baseURL)
+	if err != nil {
+		return nil, nil, err
 	}
+	p.logger.DebugContext(ctx, "prometheus query", "promql", promQL, "start", query.Start, "end", query.End,
+		"step", r.Step, "latency", time.Since(start), "series", len(series))
 	if len(warnings) > 0 {
-		// Log warnings? For now just proceed.
+		p.logger.WarnContext(ctx, "prometheus query returned warnings", "promql", promQL, "warnings", []string(warnings))
 	}
-
-	return convertResult(result, promQL, p.baseURL)
+	return series, warnings, nil
 }
 
-// Describe lists available metrics from Prometheus.
+// Describe lists available metrics from Prometheus. Prometheus warnings are
+// logged; use DescribeWithWarnings to receive them.
 func (p *PrometheusProvider) Describe(ctx context.Context, scope schema.QueryScope) ([]schema.MetricDescriptor, error) {
+	descriptors, _, err := p.DescribeWithWarnings(ctx, scope)
+	return descriptors, err
+}
+
+// DescribeWithWarnings is Describe, also returning the warnings Prometheus
+// reported.
+func (p *PrometheusProvider) DescribeWithWarnings(ctx context.Context, scope schema.QueryScope) ([]schema.MetricDescriptor, []string, error) {
 	// Use the label values API to get all metric names
 	// This corresponds to querying label values for "__name__"
+	start := time.Now()
 	values, warnings, err := p.api.LabelValues(ctx, "__name__", nil, time.Time{}, time.Time{})
 	if err != nil {
-		return nil, fmt.Errorf("failed to list metrics: %w", err)
+		err = apierr.Classify(err)
+		p.logger.DebugContext(ctx, "prometheus metric listing failed", "latency", time.Since(start),
+			"code", apierr.CodeOf(err), "error", err)
+		return nil, nil, fmt.Errorf("failed to list metrics: %w", err)
 	}
+	p.logger.DebugContext(ctx, "prometheus metric listing", "latency", time.Since(start), "metrics", len(values))
 	if len(warnings) > 0 {
-		// Log warnings
+		p.logger.WarnContext(ctx, "prometheus metric listing returned warnings", "warnings", []string(warnings))
 	}
 
 	descriptors := make([]schema.MetricDescriptor, 0, len(values))
@@ -81,7 +149,7 @@ func (p *PrometheusProvider) Describe(ctx context.Context, scope schema.QuerySco
 		})
 	}
 
-	return descriptors, nil
+	return descriptors, warnings, nil
 }
 
 func buildPromQL(query schema.MetricQuery) (string, error) {
@@ -91,7 +159,7 @@ func buildPromQL(query schema.MetricQuery) (string, error) {
 	}
 
 	if query.Expression == nil {
-		return "", fmt.Errorf("missing query expression")
+		return "", apierr.New(apierr.InvalidArgument, "missing query expression")
 	}
 
 	expr := query.Expression.MetricName
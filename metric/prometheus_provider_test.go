@@ -1,14 +1,19 @@
 package metric
 
 import (
+	"bytes"
 	"context"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-prometheus-adapter/apierr"
+	"github.com/opsorch/opsorch-prometheus-adapter/logging"
 )
 
 func TestNewPrometheusProvider(t *testing.T) {
@@ -56,6 +61,7 @@ func TestPrometheusProvider_Query(t *testing.T) {
 		expectedQuery  string
 		wantSeries     int
 		wantErr        bool
+		wantCode       apierr.Code
 		validate       func(*testing.T, []schema.MetricSeries)
 	}{
 		{
@@ -164,6 +170,7 @@ func TestPrometheusProvider_Query(t *testing.T) {
 			mockStatusCode: 400,
 			mockResponse:   `{"status":"error","errorType":"bad_data","error":"bad query"}`,
 			wantErr:        true,
+			wantCode:       apierr.BadQuery,
 		},
 		{
 			name: "malformed response",
@@ -175,6 +182,7 @@ func TestPrometheusProvider_Query(t *testing.T) {
 			},
 			mockResponse: `not json`,
 			wantErr:      true,
+			wantCode:     apierr.Unavailable,
 		},
 	}
 
@@ -215,6 +223,9 @@ func TestPrometheusProvider_Query(t *testing.T) {
 				t.Errorf("Query() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
+			if tt.wantCode != "" && apierr.CodeOf(err) != tt.wantCode {
+				t.Errorf("Query() error code = %q, want %q", apierr.CodeOf(err), tt.wantCode)
+			}
 
 			if !tt.wantErr {
 				if len(result) != tt.wantSeries {
@@ -228,6 +239,76 @@ func TestPrometheusProvider_Query(t *testing.T) {
 	}
 }
 
+func TestPrometheusProvider_QueryWarnings(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+			"status": "success",
+			"warnings": ["store unavailable"],
+			"data": {"resultType": "matrix", "result": [{"metric": {"__name__": "up"}, "values": [[1696118400, "1"]]}]}
+		}`))
+	}))
+	defer server.Close()
+
+	provider, err := NewPrometheusProvider(map[string]any{"url": server.URL})
+	if err != nil {
+		t.Fatalf("Failed to create provider: %v", err)
+	}
+
+	query := schema.MetricQuery{
+		Expression: &schema.MetricExpression{MetricName: "up"},
+		Start:      time.Unix(1696118400, 0),
+		End:        time.Unix(1696118460, 0),
+		Step:       60,
+	}
+
+	// Query keeps the plain (result, error) contract and drops the warnings.
+	result, err := provider.Query(context.Background(), query)
+	if err != nil || len(result) != 1 {
+		t.Fatalf("Query() = %v, %v, want the series without an error", result, err)
+	}
+
+	result, warnings, err := provider.QueryWithWarnings(context.Background(), query)
+	if err != nil || len(result) != 1 {
+		t.Fatalf("QueryWithWarnings() = %v, %v, want the series", result, err)
+	}
+	if len(warnings) != 1 || warnings[0] != "store unavailable" {
+		t.Errorf("warnings = %v", warnings)
+	}
+}
+
+func TestPrometheusProvider_QueryLogs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"success","data":{"resultType":"matrix","result":[{"metric":{"__name__":"up"},"values":[[1696118400,"1"]]}]}}`))
+	}))
+	defer server.Close()
+
+	var logs bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(logging.NewLogger(&logs, slog.LevelWarn))
+
+	// logLevel lowers the provider's level below the process level.
+	provider, err := NewPrometheusProvider(map[string]any{"url": server.URL, "logLevel": "debug"})
+	if err != nil {
+		t.Fatalf("Failed to create provider: %v", err)
+	}
+	_, err = provider.Query(context.Background(), schema.MetricQuery{
+		Expression: &schema.MetricExpression{MetricName: "up"},
+		Start:      time.Unix(1696118400, 0),
+		End:        time.Unix(1696118460, 0),
+		Step:       60,
+	})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+
+	line := logs.String()
+	if !strings.Contains(line, `"msg":"prometheus query"`) || !strings.Contains(line, `"promql":"up"`) || !strings.Contains(line, `"series":1`) {
+		t.Errorf("logs = %s, want the query record", line)
+	}
+}
+
 func TestPrometheusProvider_Describe(t *testing.T) {
 	mockResponse := `{
 		"status": "success",
@@ -267,3 +348,20 @@ func TestPrometheusProvider_Describe(t *testing.T) {
 		t.Errorf("Expected names %v, got %v", expected, names)
 	}
 }
+
+func TestPrometheusProvider_Close(t *testing.T) {
+	p1, err := NewPrometheusProvider(map[string]any{"url": "http://localhost:9090"})
+	if err != nil {
+		t.Fatalf("NewPrometheusProvider() error = %v", err)
+	}
+	p2, err := NewPrometheusProvider(map[string]any{"url": "http://localhost:9090"})
+	if err != nil {
+		t.Fatalf("NewPrometheusProvider() error = %v", err)
+	}
+	if p1.transport == p2.transport {
+		t.Error("expected each provider to own its transport")
+	}
+	if err := p1.Close(); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+}
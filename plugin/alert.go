@@ -0,0 +1,149 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-prometheus-adapter/alert"
+	"github.com/opsorch/opsorch-prometheus-adapter/apierr"
+	"github.com/opsorch/opsorch-prometheus-adapter/pluginrpc"
+)
+
+func (h *Host) registerAlert(srv *pluginrpc.Server) {
+	srv.Handle("alert.query", h.handleAlertQuery)
+	srv.Handle("alert.queryPage", h.handleAlertQueryPage)
+	srv.Handle("alert.get", h.handleAlertGet)
+	srv.Handle("alert.groups", h.handleAlertGroups)
+	srv.Handle("alert.rules", h.handleAlertRules)
+	srv.Handle("alert.create", h.handleAlertCreate)
+	srv.Handle("alert.resolve", h.handleAlertResolve)
+}
+
+func decodeID(req pluginrpc.Request) (string, error) {
+	var payload struct {
+		ID string `json:"id"`
+	}
+	if err := pluginrpc.DecodePayload(req, &payload); err != nil {
+		return "", err
+	}
+	if payload.ID == "" {
+		return "", apierr.New(apierr.InvalidArgument, "missing or invalid id")
+	}
+	return payload.ID, nil
+}
+
+func (h *Host) handleAlertQuery(ctx context.Context, req pluginrpc.Request) (any, error) {
+	prov, err := h.alertProvider(req.Config)
+	if err != nil {
+		return nil, err
+	}
+	var query schema.AlertQuery
+	if err := pluginrpc.DecodePayload(req, &query); err != nil {
+		return nil, fmt.Errorf("decode query: %w", err)
+	}
+	alerts, warnings, err := prov.QueryWithWarnings(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("query alerts: %w", err)
+	}
+	return alerts, partialResult(warnings)
+}
+
+func (h *Host) handleAlertQueryPage(ctx context.Context, req pluginrpc.Request) (any, error) {
+	prov, err := h.alertProvider(req.Config)
+	if err != nil {
+		return nil, err
+	}
+	var query struct {
+		schema.AlertQuery
+		alert.QueryOptions
+	}
+	if err := pluginrpc.DecodePayload(req, &query); err != nil {
+		return nil, fmt.Errorf("decode query: %w", err)
+	}
+	page, err := prov.QueryPage(ctx, query.AlertQuery, query.QueryOptions)
+	if err != nil {
+		return nil, fmt.Errorf("query alerts: %w", err)
+	}
+	return page, partialResult(page.Warnings)
+}
+
+func (h *Host) handleAlertGet(ctx context.Context, req pluginrpc.Request) (any, error) {
+	prov, err := h.alertProvider(req.Config)
+	if err != nil {
+		return nil, err
+	}
+	id, err := decodeID(req)
+	if err != nil {
+		return nil, err
+	}
+	found, warnings, err := prov.GetWithWarnings(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("get alert: %w", err)
+	}
+	return found, partialResult(warnings)
+}
+
+func (h *Host) handleAlertGroups(ctx context.Context, req pluginrpc.Request) (any, error) {
+	prov, err := h.alertProvider(req.Config)
+	if err != nil {
+		return nil, err
+	}
+	var query schema.AlertQuery
+	if err := pluginrpc.DecodePayload(req, &query); err != nil {
+		return nil, fmt.Errorf("decode query: %w", err)
+	}
+	groups, err := prov.Groups(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("query alert groups: %w", err)
+	}
+	return groups, nil
+}
+
+func (h *Host) handleAlertRules(ctx context.Context, req pluginrpc.Request) (any, error) {
+	prov, err := h.alertProvider(req.Config)
+	if err != nil {
+		return nil, err
+	}
+	var scope schema.QueryScope
+	if err := pluginrpc.DecodePayload(req, &scope); err != nil {
+		return nil, fmt.Errorf("decode scope: %w", err)
+	}
+	rules, err := prov.Rules(ctx, scope)
+	if err != nil {
+		return nil, fmt.Errorf("list alert rules: %w", err)
+	}
+	return rules, nil
+}
+
+func (h *Host) handleAlertCreate(ctx context.Context, req pluginrpc.Request) (any, error) {
+	prov, err := h.alertProvider(req.Config)
+	if err != nil {
+		return nil, err
+	}
+	var synthetic schema.Alert
+	if err := pluginrpc.DecodePayload(req, &synthetic); err != nil {
+		return nil, fmt.Errorf("decode alert: %w", err)
+	}
+	created, err := prov.Create(ctx, synthetic)
+	if err != nil {
+		return nil, fmt.Errorf("create alert: %w", err)
+	}
+	return created, nil
+}
+
+func (h *Host) handleAlertResolve(ctx context.Context, req pluginrpc.Request) (any, error) {
+	prov, err := h.alertProvider(req.Config)
+	if err != nil {
+		return nil, err
+	}
+	id, err := decodeID(req)
+	if err != nil {
+		return nil, err
+	}
+	resolved, err := prov.Resolve(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("resolve alert: %w", err)
+	}
+	return resolved, nil
+}
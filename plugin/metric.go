@@ -0,0 +1,58 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-prometheus-adapter/apierr"
+	"github.com/opsorch/opsorch-prometheus-adapter/pluginrpc"
+)
+
+func (h *Host) registerMetric(srv *pluginrpc.Server) {
+	srv.Handle("metric.query", h.handleMetricQuery)
+	srv.Handle("metric.describe", h.handleMetricDescribe)
+}
+
+func (h *Host) handleMetricQuery(ctx context.Context, req pluginrpc.Request) (any, error) {
+	prov, err := h.metricProvider(req.Config)
+	if err != nil {
+		return nil, err
+	}
+	var query schema.MetricQuery
+	if err := pluginrpc.DecodePayload(req, &query); err != nil {
+		return nil, err
+	}
+	series, warnings, err := prov.QueryWithWarnings(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return series, partialResult(warnings)
+}
+
+func (h *Host) handleMetricDescribe(ctx context.Context, req pluginrpc.Request) (any, error) {
+	prov, err := h.metricProvider(req.Config)
+	if err != nil {
+		return nil, err
+	}
+	var scope schema.QueryScope
+	if err := pluginrpc.DecodePayload(req, &scope); err != nil {
+		return nil, err
+	}
+	descriptors, warnings, err := prov.DescribeWithWarnings(ctx, scope)
+	if err != nil {
+		return nil, err
+	}
+	return descriptors, partialResult(warnings)
+}
+
+// partialResult reports warnings, which mean the result may be incomplete, as
+// an apierr.PartialResult error sent along with the result.
+func partialResult(warnings []string) error {
+	if len(warnings) == 0 {
+		return nil
+	}
+	return apierr.Wrap(apierr.PartialResult, fmt.Errorf("result may be incomplete: %s", strings.Join(warnings, "; ")),
+		map[string]any{"warnings": warnings})
+}
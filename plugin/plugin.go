@@ -0,0 +1,300 @@
+// Package plugin serves the adapter's capabilities over the plugin RPC
+// protocol. The metricplugin, alertplugin and prometheusplugin binaries are
+// thin wrappers around Main.
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+
+	version "github.com/opsorch/opsorch-prometheus-adapter"
+	"github.com/opsorch/opsorch-prometheus-adapter/alert"
+	"github.com/opsorch/opsorch-prometheus-adapter/apierr"
+	"github.com/opsorch/opsorch-prometheus-adapter/httptransport"
+	"github.com/opsorch/opsorch-prometheus-adapter/logging"
+	"github.com/opsorch/opsorch-prometheus-adapter/metric"
+	"github.com/opsorch/opsorch-prometheus-adapter/pluginrpc"
+)
+
+// Capabilities a plugin can serve.
+const (
+	Metric = "metric"
+	Alert  = "alert"
+)
+
+// HTTPSection is the config section holding the auth and TLS fields shared by
+// every capability.
+const HTTPSection = "http"
+
+// Keys of the resolved config that the provider caches are keyed by.
+const (
+	resolvedConfig    = "config"
+	resolvedTransport = "transport"
+)
+
+// Host builds and caches the providers behind a plugin's methods. Providers
+// with the same auth and TLS settings share one transport, and so one
+// connection pool, whichever capability they serve.
+type Host struct {
+	capabilities []string
+	transports   *pluginrpc.ProviderCache[*httptransport.Transport]
+	metrics      *pluginrpc.ProviderCache[*metric.PrometheusProvider]
+	alerts       *pluginrpc.ProviderCache[*alert.PrometheusAlertProvider]
+}
+
+// NewHost creates a host for the given capabilities.
+func NewHost(capabilities ...string) (*Host, error) {
+	if len(capabilities) == 0 {
+		return nil, fmt.Errorf("no capabilities")
+	}
+	for _, capability := range capabilities {
+		if capability != Metric && capability != Alert {
+			return nil, fmt.Errorf("unsupported capability: %s", capability)
+		}
+	}
+
+	h := &Host{capabilities: capabilities}
+	// Every cached provider may hold a distinct transport.
+	h.transports = pluginrpc.NewProviderCache(2*pluginrpc.DefaultCacheSize, 0, httptransport.New)
+	h.metrics = pluginrpc.NewProviderCache(0, 0, h.buildMetric)
+	h.alerts = pluginrpc.NewProviderCache(0, 0, h.buildAlert)
+	return h, nil
+}
+
+// Register registers the methods of every capability and plugin.health.
+func (h *Host) Register(srv *pluginrpc.Server) {
+	for _, capability := range h.capabilities {
+		switch capability {
+		case Metric:
+			h.registerMetric(srv)
+		case Alert:
+			h.registerAlert(srv)
+		}
+	}
+	srv.Handle("plugin.health", h.handleHealth)
+}
+
+// ConfigKeys returns the config fields read by each capability, and the
+// fields accepted in the shared http section.
+func (h *Host) ConfigKeys() map[string][]string {
+	keys := map[string][]string{HTTPSection: httptransport.Keys()}
+	for _, capability := range h.capabilities {
+		switch capability {
+		case Metric:
+			keys[Metric] = metric.ConfigKeys()
+		case Alert:
+			keys[Alert] = alert.ConfigKeys()
+		}
+	}
+	return keys
+}
+
+// Close closes every cached provider.
+func (h *Host) Close() {
+	h.metrics.Close()
+	h.alerts.Close()
+	h.transports.Close()
+}
+
+// resolve returns the config for a capability together with the transport
+// fields that apply to it. config is either flat, as OpsOrch Core sends it
+// for a single capability, or split into sections:
+//
+//	{"http": {...}, "metric": {...}, "alert": {...}}
+//
+// Transport fields in the capability's config override those in http.
+func resolve(config map[string]any, capability string) map[string]any {
+	shared, _ := config[HTTPSection].(map[string]any)
+	section, ok := config[capability].(map[string]any)
+	if !ok {
+		section = make(map[string]any, len(config))
+		for key, value := range config {
+			if key != HTTPSection {
+				section[key] = value
+			}
+		}
+	}
+
+	transport := httptransport.Select(shared)
+	for key, value := range httptransport.Select(section) {
+		transport[key] = value
+	}
+	return map[string]any{resolvedConfig: section, resolvedTransport: transport}
+}
+
+func (h *Host) transport(resolved map[string]any) (*httptransport.Transport, map[string]any, error) {
+	transport, err := h.transports.Get(resolved[resolvedTransport].(map[string]any))
+	if err != nil {
+		return nil, nil, err
+	}
+	return transport, resolved[resolvedConfig].(map[string]any), nil
+}
+
+func (h *Host) buildMetric(resolved map[string]any) (*metric.PrometheusProvider, error) {
+	transport, config, err := h.transport(resolved)
+	if err != nil {
+		return nil, err
+	}
+	prov, err := metric.NewPrometheusProviderWithTransport(config, transport)
+	if err != nil {
+		return nil, err
+	}
+	logBuild(Metric, resolved)
+	return prov, nil
+}
+
+func (h *Host) buildAlert(resolved map[string]any) (*alert.PrometheusAlertProvider, error) {
+	transport, config, err := h.transport(resolved)
+	if err != nil {
+		return nil, err
+	}
+	prov, err := alert.NewPrometheusAlertProviderWithTransport(config, transport)
+	if err != nil {
+		return nil, err
+	}
+	logBuild(Alert, resolved)
+	return prov, nil
+}
+
+// logBuild records a new provider with its config, credentials redacted, so a
+// config change shows up in the logs.
+func logBuild(capability string, resolved map[string]any) {
+	slog.Info("provider built", "capability", capability, "config", logging.RedactConfig(resolved))
+}
+
+func (h *Host) metricProvider(config map[string]any) (*metric.PrometheusProvider, error) {
+	resolved := resolve(config, Metric)
+	prov, err := h.metrics.Get(resolved)
+	if err != nil {
+		return nil, err
+	}
+	h.touchTransport(resolved)
+	return prov, nil
+}
+
+func (h *Host) alertProvider(config map[string]any) (*alert.PrometheusAlertProvider, error) {
+	resolved := resolve(config, Alert)
+	prov, err := h.alerts.Get(resolved)
+	if err != nil {
+		return nil, err
+	}
+	h.touchTransport(resolved)
+	return prov, nil
+}
+
+// touchTransport keeps the transport of a provider in use from expiring, so
+// that providers built later with the same settings still share it.
+func (h *Host) touchTransport(resolved map[string]any) {
+	h.transports.Touch(resolved[resolvedTransport].(map[string]any))
+}
+
+// handleHealth returns the backend health of a single-capability plugin, or a
+// map from capability to health for a combined plugin. In the map, a
+// capability whose provider cannot be built reports the error instead.
+func (h *Host) handleHealth(ctx context.Context, req pluginrpc.Request) (any, error) {
+	if len(h.capabilities) == 1 {
+		return h.health(ctx, h.capabilities[0], req.Config)
+	}
+
+	result := make(map[string]any, len(h.capabilities))
+	for _, capability := range h.capabilities {
+		health, err := h.health(ctx, capability, req.Config)
+		if err != nil {
+			result[capability] = pluginrpc.NewError(err)
+			continue
+		}
+		result[capability] = health
+	}
+	return result, nil
+}
+
+func (h *Host) health(ctx context.Context, capability string, config map[string]any) (any, error) {
+	switch capability {
+	case Metric:
+		prov, err := h.metricProvider(config)
+		if err != nil {
+			return nil, err
+		}
+		return prov.Health(ctx), nil
+	case Alert:
+		prov, err := h.alertProvider(config)
+		if err != nil {
+			return nil, err
+		}
+		return prov.Health(ctx), nil
+	default:
+		return nil, apierr.New(apierr.InvalidArgument, "unsupported capability: %s", capability)
+	}
+}
+
+// NewServer returns a server for the capabilities, with plugin.health,
+// plugin.info and plugin.handshake registered. Its OnStop hook closes the
+// cached providers.
+func NewServer(name string, capabilities ...string) (*pluginrpc.Server, error) {
+	host, err := NewHost(capabilities...)
+	if err != nil {
+		return nil, err
+	}
+
+	srv := pluginrpc.NewServer()
+	host.Register(srv)
+	srv.HandleInfo(pluginrpc.Info{
+		Name:         "opsorch-prometheus-adapter/" + name,
+		Version:      version.AdapterVersion,
+		RequiresCore: version.RequiresCore,
+		ConfigKeys:   host.ConfigKeys(),
+	})
+	srv.Hooks.OnStop = host.Close
+	return srv, nil
+}
+
+// Main runs a plugin binary that serves capabilities over stdin and stdout,
+// and exits with a non-zero status on error. It logs JSON to stderr at the
+// level set in logging.LevelEnv.
+func Main(name string, capabilities ...string) {
+	level, err := logging.LevelFromEnv()
+	logger := logging.NewLogger(os.Stderr, level).With("plugin", name)
+	slog.SetDefault(logger)
+	if err == nil {
+		err = run(name, capabilities)
+	}
+	if err != nil {
+		logger.Error("plugin failed", "error", err)
+		os.Exit(1)
+	}
+}
+
+func run(name string, capabilities []string) error {
+	srv, err := NewServer(name, capabilities...)
+	if err != nil {
+		return err
+	}
+
+	timeout, err := pluginrpc.TimeoutFromEnv()
+	if err != nil {
+		return err
+	}
+	srv.DefaultTimeout = timeout
+
+	workers, err := pluginrpc.WorkersFromEnv()
+	if err != nil {
+		return err
+	}
+	srv.Workers = workers
+
+	grace, err := pluginrpc.ShutdownGraceFromEnv()
+	if err != nil {
+		return err
+	}
+	srv.ShutdownGrace = grace
+
+	slog.Info("plugin started", "version", version.AdapterVersion, "capabilities", capabilities,
+		"workers", workers, "timeout", timeout)
+	if err := srv.ServeStdio(); err != nil {
+		return err
+	}
+	slog.Info("plugin stopped")
+	return nil
+}
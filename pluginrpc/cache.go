@@ -0,0 +1,164 @@
+package pluginrpc
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/opsorch/opsorch-prometheus-adapter/apierr"
+)
+
+// Defaults for NewProviderCache.
+const (
+	DefaultCacheSize = 8
+	DefaultCacheTTL  = 10 * time.Minute
+)
+
+// ProviderCache keeps providers keyed by a hash of their config, so repeated
+// requests reuse connection pools and provider state while a changed config
+// gets a new provider. Providers are evicted when the cache is full, least
+// recently used first, or when unused for the TTL. Evicted providers that
+// implement io.Closer are closed.
+type ProviderCache[T any] struct {
+	mu      sync.Mutex
+	build   func(config map[string]any) (T, error)
+	size    int
+	ttl     time.Duration
+	entries map[string]*list.Element
+	order   *list.List // front is most recently used
+	now     func() time.Time
+}
+
+type cacheEntry[T any] struct {
+	key      string
+	provider T
+	lastUsed time.Time
+}
+
+// NewProviderCache creates a cache that builds providers with build. A size
+// or ttl of zero selects DefaultCacheSize or DefaultCacheTTL.
+func NewProviderCache[T any](size int, ttl time.Duration, build func(config map[string]any) (T, error)) *ProviderCache[T] {
+	if size <= 0 {
+		size = DefaultCacheSize
+	}
+	if ttl <= 0 {
+		ttl = DefaultCacheTTL
+	}
+	return &ProviderCache[T]{
+		build:   build,
+		size:    size,
+		ttl:     ttl,
+		entries: map[string]*list.Element{},
+		order:   list.New(),
+		now:     time.Now,
+	}
+}
+
+// Get returns the provider for config, building it if needed. Errors the
+// constructor did not classify are reported as apierr.InvalidArgument, since
+// they come from the config.
+func (c *ProviderCache[T]) Get(config map[string]any) (T, error) {
+	var zero T
+	key, err := ConfigKey(config)
+	if err != nil {
+		return zero, apierr.Wrap(apierr.InvalidArgument, err, nil)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	c.expire(now)
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*cacheEntry[T])
+		entry.lastUsed = now
+		c.order.MoveToFront(elem)
+		return entry.provider, nil
+	}
+
+	provider, err := c.build(config)
+	if err != nil {
+		if apierr.CodeOf(err) == apierr.Internal {
+			err = apierr.Wrap(apierr.InvalidArgument, err, nil)
+		}
+		return zero, err
+	}
+	c.entries[key] = c.order.PushFront(&cacheEntry[T]{key: key, provider: provider, lastUsed: now})
+	for c.order.Len() > c.size {
+		c.remove(c.order.Back())
+	}
+	return provider, nil
+}
+
+// Touch marks the provider for config as used now, if it is cached, without
+// building it.
+func (c *ProviderCache[T]) Touch(config map[string]any) {
+	key, err := ConfigKey(config)
+	if err != nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		elem.Value.(*cacheEntry[T]).lastUsed = c.now()
+		c.order.MoveToFront(elem)
+	}
+}
+
+// SetClock replaces the clock the TTL is measured with. Call it before the
+// cache is used.
+func (c *ProviderCache[T]) SetClock(now func() time.Time) {
+	c.now = now
+}
+
+// Len returns the number of cached providers.
+func (c *ProviderCache[T]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// Close evicts and closes every cached provider.
+func (c *ProviderCache[T]) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for c.order.Len() > 0 {
+		c.remove(c.order.Back())
+	}
+}
+
+// expire evicts providers unused for longer than the TTL.
+func (c *ProviderCache[T]) expire(now time.Time) {
+	for elem := c.order.Back(); elem != nil; elem = c.order.Back() {
+		if now.Sub(elem.Value.(*cacheEntry[T]).lastUsed) <= c.ttl {
+			return
+		}
+		c.remove(elem)
+	}
+}
+
+func (c *ProviderCache[T]) remove(elem *list.Element) {
+	entry := c.order.Remove(elem).(*cacheEntry[T])
+	delete(c.entries, entry.key)
+	if closer, ok := any(entry.provider).(io.Closer); ok {
+		_ = closer.Close()
+	}
+}
+
+// ConfigKey returns a stable hash of a config map. encoding/json sorts map
+// keys, so equal configs hash equally regardless of key order.
+func ConfigKey(config map[string]any) (string, error) {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return "", fmt.Errorf("hash config: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
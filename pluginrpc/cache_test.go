@@ -0,0 +1,119 @@
+package pluginrpc
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/opsorch/opsorch-prometheus-adapter/apierr"
+)
+
+type fakeProvider struct {
+	url    string
+	closed bool
+}
+
+func (p *fakeProvider) Close() error {
+	p.closed = true
+	return nil
+}
+
+func newFakeCache(size int, ttl time.Duration, builds *int) *ProviderCache[*fakeProvider] {
+	return NewProviderCache(size, ttl, func(config map[string]any) (*fakeProvider, error) {
+		*builds++
+		url, _ := config["url"].(string)
+		if url == "" {
+			return nil, fmt.Errorf("missing required config field: url")
+		}
+		return &fakeProvider{url: url}, nil
+	})
+}
+
+func TestProviderCacheReuseAndRebuild(t *testing.T) {
+	builds := 0
+	cache := newFakeCache(2, time.Hour, &builds)
+
+	first, err := cache.Get(map[string]any{"url": "http://a", "timeout": "5s"})
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	again, err := cache.Get(map[string]any{"timeout": "5s", "url": "http://a"})
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if first != again || builds != 1 {
+		t.Errorf("expected the same provider for an equal config, builds = %d", builds)
+	}
+
+	changed, err := cache.Get(map[string]any{"url": "http://b"})
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if changed == first || changed.url != "http://b" {
+		t.Errorf("expected a new provider for a changed config")
+	}
+
+	// A third config evicts and closes the least recently used provider.
+	if _, err := cache.Get(map[string]any{"url": "http://c"}); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if !first.closed || changed.closed || cache.Len() != 2 {
+		t.Errorf("first closed = %v, changed closed = %v, len = %d", first.closed, changed.closed, cache.Len())
+	}
+
+	if _, err := cache.Get(map[string]any{}); apierr.CodeOf(err) != apierr.InvalidArgument {
+		t.Errorf("Get() error = %v, want invalid_argument build error", err)
+	}
+	if cache.Len() != 2 {
+		t.Errorf("failed builds must not be cached, len = %d", cache.Len())
+	}
+
+	cache.Close()
+	if !changed.closed || cache.Len() != 0 {
+		t.Errorf("Close() left providers open")
+	}
+}
+
+func TestProviderCacheTTL(t *testing.T) {
+	builds := 0
+	cache := newFakeCache(0, time.Minute, &builds)
+	now := time.Date(2025, 12, 3, 10, 0, 0, 0, time.UTC)
+	cache.now = func() time.Time { return now }
+
+	old, err := cache.Get(map[string]any{"url": "http://a"})
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, err := cache.Get(map[string]any{"url": "http://b"}); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if !old.closed || cache.Len() != 1 {
+		t.Errorf("expected idle provider to expire, closed = %v, len = %d", old.closed, cache.Len())
+	}
+}
+
+func TestProviderCacheTouch(t *testing.T) {
+	builds := 0
+	cache := newFakeCache(0, time.Minute, &builds)
+	now := time.Date(2025, 12, 3, 10, 0, 0, 0, time.UTC)
+	cache.SetClock(func() time.Time { return now })
+
+	config := map[string]any{"url": "http://a"}
+	if _, err := cache.Get(config); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		now = now.Add(45 * time.Second)
+		cache.Touch(config)
+	}
+	cache.Touch(map[string]any{"url": "http://b"})
+
+	if _, err := cache.Get(config); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if builds != 1 || cache.Len() != 1 {
+		t.Errorf("builds = %d, len = %d, want the touched provider kept and nothing built", builds, cache.Len())
+	}
+}
@@ -0,0 +1,89 @@
+package pluginrpc
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/opsorch/opsorch-prometheus-adapter/apierr"
+)
+
+// ProtocolVersion is the version of the message format served by Server.
+// Callers negotiate it with the plugin.handshake method.
+const ProtocolVersion = 1
+
+// supportedProtocolVersions lists the protocol versions Server can speak.
+var supportedProtocolVersions = []int{ProtocolVersion}
+
+// Introspection methods registered by HandleInfo.
+const (
+	InfoMethod      = "plugin.info"
+	HandshakeMethod = "plugin.handshake"
+)
+
+// Info describes a plugin binary for plugin.info and plugin.handshake.
+type Info struct {
+	Name         string `json:"name"`
+	Version      string `json:"version"`
+	RequiresCore string `json:"requiresCore,omitempty"`
+	// ProtocolVersion and Methods are filled in by the server.
+	ProtocolVersion int      `json:"protocolVersion"`
+	Methods         []string `json:"methods"`
+	// ConfigKeys lists the config fields each capability reads.
+	ConfigKeys map[string][]string `json:"configKeys,omitempty"`
+}
+
+// Handshake is the plugin.handshake result: the negotiated protocol version
+// and the plugin description.
+type Handshake struct {
+	ProtocolVersion int  `json:"protocolVersion"`
+	Info            Info `json:"info"`
+}
+
+// HandleInfo registers plugin.info and plugin.handshake for info.
+//
+// plugin.handshake takes {"protocolVersions": [...]}, the versions the caller
+// speaks, and picks the highest one the server also speaks. It fails with
+// apierr.InvalidArgument when there is none. An empty list selects
+// ProtocolVersion.
+func (s *Server) HandleInfo(info Info) {
+	describe := func() Info {
+		described := info
+		described.ProtocolVersion = ProtocolVersion
+		described.Methods = s.Methods()
+		return described
+	}
+
+	s.Handle(InfoMethod, func(ctx context.Context, req Request) (any, error) {
+		return describe(), nil
+	})
+	s.Handle(HandshakeMethod, func(ctx context.Context, req Request) (any, error) {
+		var payload struct {
+			ProtocolVersions []int `json:"protocolVersions"`
+		}
+		if err := DecodePayload(req, &payload); err != nil {
+			return nil, err
+		}
+		version, ok := negotiate(payload.ProtocolVersions)
+		if !ok {
+			err := fmt.Errorf("unsupported protocol versions %v: plugin speaks %v", payload.ProtocolVersions, supportedProtocolVersions)
+			return nil, apierr.Wrap(apierr.InvalidArgument, err, map[string]any{"supported": supportedProtocolVersions})
+		}
+		return Handshake{ProtocolVersion: version, Info: describe()}, nil
+	})
+}
+
+// negotiate returns the highest version in offered that the server speaks.
+func negotiate(offered []int) (int, bool) {
+	if len(offered) == 0 {
+		return ProtocolVersion, true
+	}
+	best, ok := 0, false
+	for _, version := range offered {
+		for _, supported := range supportedProtocolVersions {
+			if version == supported && version > best {
+				best, ok = version, true
+			}
+		}
+	}
+	return best, ok
+}
@@ -0,0 +1,59 @@
+package pluginrpc
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/opsorch/opsorch-prometheus-adapter/apierr"
+)
+
+func TestHandleInfo(t *testing.T) {
+	srv := newEchoServer()
+	srv.HandleInfo(Info{
+		Name:       "test",
+		Version:    "1.2.3",
+		ConfigKeys: map[string][]string{"metric": {"url"}},
+	})
+
+	resp := srv.Dispatch(context.Background(), Request{Method: InfoMethod})
+	info, ok := resp.Result.(Info)
+	if !ok || resp.Error != nil {
+		t.Fatalf("plugin.info = %+v", resp)
+	}
+	if info.Version != "1.2.3" || info.ProtocolVersion != ProtocolVersion || len(info.ConfigKeys["metric"]) != 1 {
+		t.Errorf("info = %+v", info)
+	}
+	if got := strings.Join(info.Methods, ","); got != "echo,plugin.handshake,plugin.info" {
+		t.Errorf("methods = %v, want echo and the introspection methods", got)
+	}
+}
+
+func TestHandshake(t *testing.T) {
+	srv := NewServer()
+	srv.HandleInfo(Info{Name: "test", Version: "1.2.3"})
+
+	tests := []struct {
+		payload string
+		want    int
+		code    apierr.Code
+	}{
+		{``, ProtocolVersion, ""},
+		{`{"protocolVersions":[1,7]}`, 1, ""},
+		{`{"protocolVersions":[7]}`, 0, apierr.InvalidArgument},
+	}
+	for _, tt := range tests {
+		resp := srv.Dispatch(context.Background(), Request{Method: HandshakeMethod, Payload: json.RawMessage(tt.payload)})
+		if tt.code != "" {
+			if resp.Error == nil || resp.Error.Code != tt.code || resp.Error.Details["supported"] == nil {
+				t.Errorf("handshake %s = %+v, want %s", tt.payload, resp.Error, tt.code)
+			}
+			continue
+		}
+		handshake, ok := resp.Result.(Handshake)
+		if !ok || handshake.ProtocolVersion != tt.want || handshake.Info.Version != "1.2.3" {
+			t.Errorf("handshake %s = %+v, want version %d", tt.payload, resp, tt.want)
+		}
+	}
+}
@@ -0,0 +1,590 @@
+// Package pluginrpc implements the JSON request/response loop shared by the
+// OpsOrch plugin binaries. Requests and responses are newline-delimited JSON
+// objects read from stdin and written to stdout.
+package pluginrpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"reflect"
+	"runtime/debug"
+	"sort"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/opsorch/opsorch-prometheus-adapter/apierr"
+	"github.com/opsorch/opsorch-prometheus-adapter/logging"
+)
+
+// CancelMethod is the control message that cancels an in-flight request. Its
+// payload is {"id": "<request id>"}; it gets no response.
+const CancelMethod = "cancel"
+
+// DefaultTimeout bounds requests that carry no timeoutMs or deadline.
+const DefaultTimeout = 60 * time.Second
+
+// TimeoutEnv overrides DefaultTimeout for the plugin binaries, as a Go duration.
+const TimeoutEnv = "OPSORCH_PLUGIN_TIMEOUT"
+
+// DefaultWorkers is the number of requests with IDs handled concurrently.
+const DefaultWorkers = 4
+
+// WorkersEnv overrides DefaultWorkers for the plugin binaries.
+const WorkersEnv = "OPSORCH_PLUGIN_WORKERS"
+
+// DefaultShutdownGrace is how long in-flight requests may run once Serve
+// begins shutting down.
+const DefaultShutdownGrace = 10 * time.Second
+
+// ShutdownGraceEnv overrides DefaultShutdownGrace for the plugin binaries, as
+// a Go duration.
+const ShutdownGraceEnv = "OPSORCH_PLUGIN_SHUTDOWN_GRACE"
+
+// queueSize is the number of decoded requests that may wait for a worker.
+const queueSize = 64
+
+// Request is a single plugin call.
+type Request struct {
+	// ID identifies the request in its response and in cancel messages.
+	ID      string          `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Config  map[string]any  `json:"config"`
+	Payload json.RawMessage `json:"payload"`
+	// TimeoutMs and Deadline bound the request; the earlier one wins.
+	TimeoutMs int64     `json:"timeoutMs,omitempty"`
+	Deadline  time.Time `json:"deadline,omitempty"`
+}
+
+// Response is the reply to a Request. Exactly one of Result and Error is set,
+// except that a partial_result error comes with the partial Result.
+type Response struct {
+	ID     string `json:"id,omitempty"`
+	Result any    `json:"result,omitempty"`
+	Error  *Error `json:"error,omitempty"`
+}
+
+// Error is the serialized form of a failed request.
+type Error struct {
+	Code    apierr.Code    `json:"code"`
+	Message string         `json:"message"`
+	Details map[string]any `json:"details,omitempty"`
+}
+
+// NewError builds the response error for err, classified with apierr.CodeOf.
+func NewError(err error) *Error {
+	return &Error{
+		Code:    apierr.CodeOf(err),
+		Message: err.Error(),
+		Details: apierr.DetailsOf(err),
+	}
+}
+
+// HandlerFunc handles one method. The returned value becomes Response.Result.
+type HandlerFunc func(ctx context.Context, req Request) (any, error)
+
+// Hooks are optional lifecycle callbacks.
+type Hooks struct {
+	// OnStart runs before the first request is read; an error aborts Serve.
+	OnStart func() error
+	// OnRequest runs before each request is dispatched. Like OnResponse, it
+	// may be called concurrently for requests with IDs.
+	OnRequest func(ctx context.Context, req Request)
+	// OnResponse runs after each request, before the response is written.
+	OnResponse func(ctx context.Context, req Request, resp Response)
+	// OnStop runs when Serve returns.
+	OnStop func()
+}
+
+// Server dispatches requests to registered method handlers.
+type Server struct {
+	Hooks Hooks
+	// DefaultTimeout bounds requests without their own timeout.
+	DefaultTimeout time.Duration
+	// Workers bounds the number of requests with IDs handled concurrently.
+	Workers int
+	// ShutdownGrace bounds how long in-flight requests may run after Serve
+	// stops accepting requests; when it expires they are canceled.
+	ShutdownGrace time.Duration
+	// Logger records each request served by Serve; nil disables logging.
+	// Handler panics are logged to slog.Default() when it is nil.
+	Logger *slog.Logger
+
+	handlers map[string]HandlerFunc
+
+	mu       sync.Mutex
+	inflight map[string]context.CancelFunc
+}
+
+// call is a decoded request waiting for or running on a worker.
+type call struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	req    Request
+}
+
+// NewServer creates a server with no methods registered.
+func NewServer() *Server {
+	return &Server{
+		DefaultTimeout: DefaultTimeout,
+		Workers:        DefaultWorkers,
+		ShutdownGrace:  DefaultShutdownGrace,
+		Logger:         slog.Default(),
+		handlers:       map[string]HandlerFunc{},
+		inflight:       map[string]context.CancelFunc{},
+	}
+}
+
+// Handle registers the handler for a method, replacing any existing one.
+func (s *Server) Handle(method string, handler HandlerFunc) {
+	s.handlers[method] = handler
+}
+
+// Methods returns the registered method names, sorted.
+func (s *Server) Methods() []string {
+	methods := make([]string, 0, len(s.handlers))
+	for method := range s.handlers {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+	return methods
+}
+
+// Dispatch runs the handler for a request and builds its response. Errors
+// are classified with apierr; once ctx has ended, they are reported as
+// timeout or canceled whatever the handler returned. A handler that panics
+// gets an internal error response, and the panic and its stack are logged.
+func (s *Server) Dispatch(ctx context.Context, req Request) Response {
+	resp := Response{ID: req.ID}
+	handler, ok := s.handlers[req.Method]
+	if !ok {
+		resp.Error = NewError(apierr.New(apierr.InvalidArgument, "unknown method: %s", req.Method))
+		return resp
+	}
+
+	var (
+		result any
+		err    = ctx.Err()
+	)
+	if err == nil {
+		result, err = s.call(ctx, handler, req)
+	}
+	if err != nil {
+		resp.Error = NewError(err)
+		if code := contextCode(ctx); code != "" {
+			resp.Error.Code = code
+		}
+		if resp.Error.Code != apierr.PartialResult {
+			return resp
+		}
+	}
+	resp.Result = result
+	return resp
+}
+
+// call runs handler, turning a panic into an internal error.
+func (s *Server) call(ctx context.Context, handler HandlerFunc, req Request) (result any, err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			logger := s.Logger
+			if logger == nil {
+				logger = slog.Default()
+			}
+			logger.ErrorContext(ctx, "handler panicked", "panic", fmt.Sprint(v), "stack", string(debug.Stack()))
+			result, err = nil, apierr.New(apierr.Internal, "internal error: %s panicked: %v", req.Method, v)
+		}
+	}()
+	return handler(ctx, req)
+}
+
+// Serve reads requests from r and writes responses to w until r is exhausted
+// or ctx ends. Requests with an ID run concurrently on up to Workers
+// goroutines and their responses are written as they complete, tagged with
+// the ID. A request without an ID waits for in-flight requests and runs
+// alone, so callers that send no IDs get responses in request order. A
+// request with fields of the wrong type gets an invalid_argument response;
+// input that is not valid JSON gets an error response and stops the loop,
+// since the rest of the stream cannot be trusted.
+//
+// Once r is exhausted or ctx ends, Serve stops accepting requests; any still
+// being read get an unavailable error. In-flight and queued requests have
+// ShutdownGrace to finish before they are canceled, and Serve returns after
+// every accepted request has been answered.
+func (s *Server) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	if s.Hooks.OnStop != nil {
+		defer s.Hooks.OnStop()
+	}
+	if s.Hooks.OnStart != nil {
+		if err := s.Hooks.OnStart(); err != nil {
+			return fmt.Errorf("start: %w", err)
+		}
+	}
+
+	workers := s.Workers
+	if workers <= 0 {
+		workers = 1
+	}
+
+	// Requests run under base rather than ctx, so that ending ctx starts the
+	// grace period instead of canceling them.
+	base, cancelAll := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelAll()
+
+	out := &responseWriter{enc: json.NewEncoder(w)}
+	defer out.close()
+	queue := make(chan call, queueSize)
+	var (
+		pending sync.WaitGroup // queued or running requests
+		pool    sync.WaitGroup // worker goroutines
+	)
+	for i := 0; i < workers; i++ {
+		pool.Add(1)
+		go func() {
+			defer pool.Done()
+			for c := range queue {
+				s.run(c, out)
+				pending.Done()
+			}
+		}()
+	}
+
+	stopping := make(chan struct{})
+	finished := make(chan struct{})
+	defer close(finished)
+	var once sync.Once
+	shutdown := func() {
+		once.Do(func() {
+			close(stopping)
+			go func() {
+				timer := time.NewTimer(s.ShutdownGrace)
+				defer timer.Stop()
+				select {
+				case <-timer.C:
+					cancelAll()
+				case <-finished:
+				}
+			}()
+		})
+	}
+	go func() {
+		select {
+		case <-ctx.Done():
+			if s.Logger != nil {
+				s.Logger.Info("shutting down", "grace", s.ShutdownGrace)
+			}
+			shutdown()
+		case <-finished:
+		}
+	}()
+
+	incoming := make(chan call)
+	readDone := make(chan error, 1)
+	go func() {
+		readDone <- s.read(base, r, incoming, stopping, out)
+	}()
+
+	var readErr error
+loop:
+	for {
+		select {
+		case c := <-incoming:
+			if ctx.Err() != nil {
+				s.reject(c, out)
+				continue
+			}
+			if c.req.ID == "" {
+				pending.Wait()
+				s.run(c, out)
+				continue
+			}
+			pending.Add(1)
+			queue <- c
+		case readErr = <-readDone:
+			break loop
+		case <-stopping:
+			break loop
+		case <-ctx.Done():
+			break loop
+		}
+	}
+	shutdown()
+	close(queue)
+	pool.Wait()
+
+	if readErr != nil {
+		if s.Logger != nil {
+			s.Logger.ErrorContext(ctx, "stopped reading requests", "error", readErr)
+		}
+		_ = out.write(Response{Error: NewError(readErr)})
+		return readErr
+	}
+	return out.err()
+}
+
+// read decodes requests and hands them to Serve on incoming until r is
+// exhausted or is not valid JSON. A well-formed request with fields of the
+// wrong type is answered with an invalid_argument error and reading goes on.
+// Once stopping is closed, requests are answered with an unavailable error
+// instead.
+func (s *Server) read(ctx context.Context, r io.Reader, incoming chan<- call, stopping <-chan struct{}, out *responseWriter) error {
+	dec := json.NewDecoder(r)
+	for {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return apierr.New(apierr.InvalidArgument, "decode request: %w", err)
+		}
+		var req Request
+		if err := json.Unmarshal(raw, &req); err != nil {
+			// The value was read whole, so the stream is still in sync.
+			var id struct {
+				ID string `json:"id"`
+			}
+			_ = json.Unmarshal(raw, &id)
+			_ = out.write(Response{ID: id.ID, Error: NewError(apierr.New(apierr.InvalidArgument, "decode request: %w", err))})
+			continue
+		}
+
+		if req.Method == CancelMethod {
+			var target struct {
+				ID string `json:"id"`
+			}
+			if err := DecodePayload(req, &target); err == nil {
+				s.cancel(target.ID)
+			}
+			continue
+		}
+
+		reqCtx, cancel := s.requestContext(ctx, req)
+		s.track(req.ID, cancel)
+		c := call{ctx: reqCtx, cancel: cancel, req: req}
+		select {
+		case incoming <- c:
+		case <-stopping:
+			s.reject(c, out)
+		}
+	}
+}
+
+// reject answers a request that arrived after shutdown began.
+func (s *Server) reject(c call, out *responseWriter) {
+	s.untrack(c.req.ID)
+	c.cancel()
+	_ = out.write(Response{ID: c.req.ID, Error: NewError(apierr.New(apierr.Unavailable, "plugin is shutting down"))})
+}
+
+// run dispatches one request and writes its response. The handler's context
+// carries the method and request ID for loggers from the logging package.
+func (s *Server) run(c call, out *responseWriter) {
+	defer c.cancel()
+	defer s.untrack(c.req.ID)
+
+	ctx := logging.WithContext(c.ctx, slog.String("method", c.req.Method), slog.String("requestId", c.req.ID))
+	if s.Hooks.OnRequest != nil {
+		s.Hooks.OnRequest(ctx, c.req)
+	}
+	start := time.Now()
+	resp := s.Dispatch(ctx, c.req)
+	s.logResponse(c.ctx, c.req, resp, time.Since(start))
+	if s.Hooks.OnResponse != nil {
+		s.Hooks.OnResponse(ctx, c.req, resp)
+	}
+	_ = out.write(resp)
+}
+
+// logResponse records a finished request: at info level when it succeeded,
+// at warn level when it failed, and at error level for internal errors.
+func (s *Server) logResponse(ctx context.Context, req Request, resp Response, latency time.Duration) {
+	if s.Logger == nil {
+		return
+	}
+	attrs := []any{"method", req.Method, "requestId", req.ID, "latency", latency}
+	if n, ok := resultSize(resp.Result); ok {
+		attrs = append(attrs, "items", n)
+	}
+	if resp.Error == nil {
+		s.Logger.InfoContext(ctx, "request completed", attrs...)
+		return
+	}
+	attrs = append(attrs, "code", resp.Error.Code, "error", resp.Error.Message)
+	level := slog.LevelWarn
+	if resp.Error.Code == apierr.Internal {
+		level = slog.LevelError
+	}
+	s.Logger.Log(ctx, level, "request failed", attrs...)
+}
+
+// resultSize returns the length of a slice or map result.
+func resultSize(result any) (int, bool) {
+	v := reflect.ValueOf(result)
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map:
+		return v.Len(), true
+	default:
+		return 0, false
+	}
+}
+
+// requestContext derives the context for a request from its deadline,
+// timeoutMs or the server default, whichever ends first.
+func (s *Server) requestContext(parent context.Context, req Request) (context.Context, context.CancelFunc) {
+	var deadline time.Time
+	if s.DefaultTimeout > 0 {
+		deadline = time.Now().Add(s.DefaultTimeout)
+	}
+	if req.TimeoutMs > 0 {
+		if d := time.Now().Add(time.Duration(req.TimeoutMs) * time.Millisecond); deadline.IsZero() || d.Before(deadline) {
+			deadline = d
+		}
+	}
+	if !req.Deadline.IsZero() && (deadline.IsZero() || req.Deadline.Before(deadline)) {
+		deadline = req.Deadline
+	}
+	if deadline.IsZero() {
+		return context.WithCancel(parent)
+	}
+	return context.WithDeadline(parent, deadline)
+}
+
+func (s *Server) track(id string, cancel context.CancelFunc) {
+	if id == "" {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.inflight[id] = cancel
+}
+
+func (s *Server) untrack(id string) {
+	if id == "" {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.inflight, id)
+}
+
+func (s *Server) cancel(id string) {
+	s.mu.Lock()
+	cancel, ok := s.inflight[id]
+	s.mu.Unlock()
+	if ok {
+		cancel()
+	}
+}
+
+// contextCode returns the error code for a request whose ctx has ended, or ""
+// while it is still live.
+func contextCode(ctx context.Context) apierr.Code {
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return apierr.Timeout
+	case errors.Is(ctx.Err(), context.Canceled):
+		return apierr.Canceled
+	default:
+		return ""
+	}
+}
+
+// responseWriter serializes responses onto the output stream and remembers
+// the first write error. Responses written after close are dropped.
+type responseWriter struct {
+	mu       sync.Mutex
+	enc      *json.Encoder
+	writeErr error
+	closed   bool
+}
+
+func (w *responseWriter) write(resp Response) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.writeErr != nil || w.closed {
+		return w.writeErr
+	}
+	if err := w.enc.Encode(resp); err != nil {
+		w.writeErr = fmt.Errorf("encode response: %w", err)
+	}
+	return w.writeErr
+}
+
+func (w *responseWriter) close() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.closed = true
+}
+
+func (w *responseWriter) err() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.writeErr
+}
+
+// ServeStdio serves requests from stdin to stdout until stdin is closed or
+// the process receives SIGTERM or an interrupt, then shuts down as Serve
+// describes.
+func (s *Server) ServeStdio() error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return s.Serve(ctx, os.Stdin, os.Stdout)
+}
+
+// WorkersFromEnv returns the worker count set in WorkersEnv, or DefaultWorkers.
+func WorkersFromEnv() (int, error) {
+	value := os.Getenv(WorkersEnv)
+	if value == "" {
+		return DefaultWorkers, nil
+	}
+	workers, err := strconv.Atoi(value)
+	if err != nil || workers < 1 {
+		return 0, fmt.Errorf("invalid %s: %q", WorkersEnv, value)
+	}
+	return workers, nil
+}
+
+// ShutdownGraceFromEnv returns the grace period set in ShutdownGraceEnv, or
+// DefaultShutdownGrace.
+func ShutdownGraceFromEnv() (time.Duration, error) {
+	value := os.Getenv(ShutdownGraceEnv)
+	if value == "" {
+		return DefaultShutdownGrace, nil
+	}
+	grace, err := time.ParseDuration(value)
+	if err != nil || grace < 0 {
+		return 0, fmt.Errorf("invalid %s: %q", ShutdownGraceEnv, value)
+	}
+	return grace, nil
+}
+
+// TimeoutFromEnv returns the timeout set in TimeoutEnv, or DefaultTimeout.
+func TimeoutFromEnv() (time.Duration, error) {
+	value := os.Getenv(TimeoutEnv)
+	if value == "" {
+		return DefaultTimeout, nil
+	}
+	timeout, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", TimeoutEnv, err)
+	}
+	return timeout, nil
+}
+
+// DecodePayload unmarshals the request payload into v. A missing or null
+// payload leaves v unchanged. Failures are apierr.InvalidArgument errors.
+func DecodePayload(req Request, v any) error {
+	if len(req.Payload) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(req.Payload, v); err != nil {
+		return apierr.New(apierr.InvalidArgument, "decode payload: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,454 @@
+package pluginrpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/opsorch/opsorch-prometheus-adapter/apierr"
+	"github.com/opsorch/opsorch-prometheus-adapter/logging"
+)
+
+func newEchoServer() *Server {
+	srv := NewServer()
+	srv.Handle("echo", func(ctx context.Context, req Request) (any, error) {
+		var payload struct {
+			Message string `json:"message"`
+		}
+		if err := DecodePayload(req, &payload); err != nil {
+			return nil, err
+		}
+		if payload.Message == "" {
+			return nil, fmt.Errorf("missing message")
+		}
+		return payload.Message, nil
+	})
+	return srv
+}
+
+func decodeResponses(t *testing.T, out *bytes.Buffer) []Response {
+	t.Helper()
+	var responses []Response
+	dec := json.NewDecoder(out)
+	for dec.More() {
+		var resp Response
+		if err := dec.Decode(&resp); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+		responses = append(responses, resp)
+	}
+	return responses
+}
+
+func TestServe(t *testing.T) {
+	srv := newEchoServer()
+	in := strings.NewReader(`{"method":"echo","payload":{"message":"hello"}}
+{"method":"echo","payload":{}}
+{"method":"missing"}
+`)
+	var out bytes.Buffer
+
+	if err := srv.Serve(context.Background(), in, &out); err != nil {
+		t.Fatalf("Serve() error = %v", err)
+	}
+
+	responses := decodeResponses(t, &out)
+	if len(responses) != 3 {
+		t.Fatalf("expected 3 responses, got %d", len(responses))
+	}
+	if responses[0].Result != "hello" || responses[0].Error != nil {
+		t.Errorf("response 0 = %+v, want hello", responses[0])
+	}
+	if err := responses[1].Error; err == nil || err.Message != "missing message" || err.Code != apierr.Internal {
+		t.Errorf("response 1 = %+v, want internal handler error", err)
+	}
+	if err := responses[2].Error; err == nil || err.Message != "unknown method: missing" || err.Code != apierr.InvalidArgument {
+		t.Errorf("response 2 = %+v, want unknown method", err)
+	}
+}
+
+func TestServeStopsOnDecodeError(t *testing.T) {
+	srv := newEchoServer()
+	in := strings.NewReader(`{"method":"echo","payload":{"message":"hello"}}
+not json
+{"method":"echo","payload":{"message":"unreachable"}}
+`)
+	var out bytes.Buffer
+
+	if err := srv.Serve(context.Background(), in, &out); err == nil {
+		t.Fatal("expected decode error")
+	}
+
+	responses := decodeResponses(t, &out)
+	if len(responses) != 2 {
+		t.Fatalf("expected a result and one decode error, got %+v", responses)
+	}
+	if err := responses[1].Error; err == nil || !strings.HasPrefix(err.Message, "decode request:") || err.Code != apierr.InvalidArgument {
+		t.Errorf("response 1 = %+v, want decode error", err)
+	}
+}
+
+func TestServeContinuesAfterTypeError(t *testing.T) {
+	srv := newEchoServer()
+	in := strings.NewReader(`{"id":"1","method":"echo","timeoutMs":"5","payload":{"message":"bad"}}
+{"id":"2","method":"echo","payload":{"message":"hello"}}
+`)
+	var out bytes.Buffer
+
+	if err := srv.Serve(context.Background(), in, &out); err != nil {
+		t.Fatalf("Serve() error = %v", err)
+	}
+
+	responses := decodeResponses(t, &out)
+	if len(responses) != 2 {
+		t.Fatalf("expected two responses, got %+v", responses)
+	}
+	if resp := responses[0]; resp.ID != "1" || resp.Error == nil || resp.Error.Code != apierr.InvalidArgument {
+		t.Errorf("response 0 = %+v, want invalid_argument for request 1", resp)
+	}
+	if resp := responses[1]; resp.ID != "2" || resp.Error != nil {
+		t.Errorf("response 1 = %+v, want a result for request 2", resp)
+	}
+}
+
+func TestHooks(t *testing.T) {
+	srv := newEchoServer()
+	var events []string
+	srv.Hooks = Hooks{
+		OnStart:   func() error { events = append(events, "start"); return nil },
+		OnRequest: func(ctx context.Context, req Request) { events = append(events, "request:"+req.Method) },
+		OnResponse: func(ctx context.Context, req Request, resp Response) {
+			events = append(events, "response:"+resp.Error.Message)
+		},
+		OnStop: func() { events = append(events, "stop") },
+	}
+
+	var out bytes.Buffer
+	if err := srv.Serve(context.Background(), strings.NewReader(`{"method":"nope"}`), &out); err != nil {
+		t.Fatalf("Serve() error = %v", err)
+	}
+
+	want := []string{"start", "request:nope", "response:unknown method: nope", "stop"}
+	if strings.Join(events, ",") != strings.Join(want, ",") {
+		t.Errorf("events = %v, want %v", events, want)
+	}
+
+	srv.Hooks = Hooks{OnStart: func() error { return fmt.Errorf("boom") }}
+	if err := srv.Serve(context.Background(), strings.NewReader(`{"method":"echo"}`), &out); err == nil {
+		t.Error("expected OnStart error to abort Serve")
+	}
+}
+
+func TestMethods(t *testing.T) {
+	srv := newEchoServer()
+	srv.Handle("alpha", func(ctx context.Context, req Request) (any, error) { return nil, nil })
+	if got := strings.Join(srv.Methods(), ","); got != "alpha,echo" {
+		t.Errorf("Methods() = %v, want alpha,echo", got)
+	}
+}
+
+func newBlockingServer() *Server {
+	srv := NewServer()
+	srv.Handle("block", func(ctx context.Context, req Request) (any, error) {
+		<-ctx.Done()
+		return nil, fmt.Errorf("query prometheus: %w", ctx.Err())
+	})
+	return srv
+}
+
+func TestServeTimeout(t *testing.T) {
+	srv := newBlockingServer()
+	srv.DefaultTimeout = time.Hour
+
+	in := strings.NewReader(`{"id":"1","method":"block","timeoutMs":10}
+{"id":"2","method":"block","deadline":"2000-01-01T00:00:00Z"}
+`)
+	var out bytes.Buffer
+	if err := srv.Serve(context.Background(), in, &out); err != nil {
+		t.Fatalf("Serve() error = %v", err)
+	}
+
+	responses := decodeResponses(t, &out)
+	if len(responses) != 2 {
+		t.Fatalf("expected 2 responses, got %+v", responses)
+	}
+	seen := map[string]bool{}
+	for _, resp := range responses {
+		if resp.Error == nil || resp.Error.Code != apierr.Timeout {
+			t.Errorf("response %s = %+v, want timeout", resp.ID, resp)
+		}
+		seen[resp.ID] = true
+	}
+	if !seen["1"] || !seen["2"] {
+		t.Errorf("responses = %+v, want one for each request", responses)
+	}
+}
+
+func TestServeCancel(t *testing.T) {
+	srv := newBlockingServer()
+	srv.DefaultTimeout = time.Hour
+
+	in := strings.NewReader(`{"id":"slow","method":"block"}
+{"method":"cancel","payload":{"id":"slow"}}
+`)
+	var out bytes.Buffer
+	if err := srv.Serve(context.Background(), in, &out); err != nil {
+		t.Fatalf("Serve() error = %v", err)
+	}
+
+	responses := decodeResponses(t, &out)
+	if len(responses) != 1 {
+		t.Fatalf("expected only the canceled request to get a response, got %+v", responses)
+	}
+	if responses[0].ID != "slow" || responses[0].Error == nil || responses[0].Error.Code != apierr.Canceled {
+		t.Errorf("response = %+v, want canceled", responses[0])
+	}
+}
+
+func TestDispatchErrors(t *testing.T) {
+	srv := NewServer()
+	srv.Handle("missing", func(ctx context.Context, req Request) (any, error) {
+		return nil, fmt.Errorf("get alert: %w", apierr.Wrap(apierr.NotFound, fmt.Errorf("alert not found: x"), map[string]any{"id": "x"}))
+	})
+	srv.Handle("partial", func(ctx context.Context, req Request) (any, error) {
+		return []int{1}, apierr.New(apierr.PartialResult, "prometheus returned warnings")
+	})
+	srv.Handle("payload", func(ctx context.Context, req Request) (any, error) {
+		var n int
+		return nil, DecodePayload(req, &n)
+	})
+
+	resp := srv.Dispatch(context.Background(), Request{Method: "missing"})
+	if resp.Error == nil || resp.Error.Code != apierr.NotFound || resp.Error.Message != "get alert: alert not found: x" || resp.Error.Details["id"] != "x" {
+		t.Errorf("missing = %+v", resp.Error)
+	}
+
+	resp = srv.Dispatch(context.Background(), Request{Method: "partial"})
+	if resp.Error == nil || resp.Error.Code != apierr.PartialResult || resp.Result == nil {
+		t.Errorf("partial = %+v, want result and partial_result error", resp)
+	}
+
+	resp = srv.Dispatch(context.Background(), Request{Method: "payload", Payload: json.RawMessage(`"x"`)})
+	if resp.Error == nil || resp.Error.Code != apierr.InvalidArgument {
+		t.Errorf("payload = %+v, want invalid_argument", resp.Error)
+	}
+}
+
+func TestRequestContext(t *testing.T) {
+	srv := NewServer()
+	srv.DefaultTimeout = time.Minute
+
+	ctx, cancel := srv.requestContext(context.Background(), Request{TimeoutMs: 1000})
+	defer cancel()
+	if deadline, ok := ctx.Deadline(); !ok || time.Until(deadline) > 2*time.Second {
+		t.Errorf("deadline = %v, want timeoutMs to shorten the default", deadline)
+	}
+
+	srv.DefaultTimeout = 0
+	ctx, cancel = srv.requestContext(context.Background(), Request{})
+	defer cancel()
+	if _, ok := ctx.Deadline(); ok {
+		t.Error("expected no deadline without any timeout")
+	}
+}
+
+func TestServeConcurrentRequests(t *testing.T) {
+	srv := NewServer()
+	srv.Workers = 2
+	release := make(chan struct{})
+	srv.Handle("wait", func(ctx context.Context, req Request) (any, error) {
+		select {
+		case <-release:
+			return "waited", nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	})
+	srv.Handle("release", func(ctx context.Context, req Request) (any, error) {
+		close(release)
+		return "released", nil
+	})
+
+	// "slow" can only finish after "fast" runs, so they must run concurrently
+	// and the responses come back out of order.
+	in := strings.NewReader(`{"id":"slow","method":"wait","timeoutMs":5000}
+{"id":"fast","method":"release"}
+`)
+	var out bytes.Buffer
+	if err := srv.Serve(context.Background(), in, &out); err != nil {
+		t.Fatalf("Serve() error = %v", err)
+	}
+
+	responses := decodeResponses(t, &out)
+	if len(responses) != 2 {
+		t.Fatalf("expected 2 responses, got %+v", responses)
+	}
+	if responses[0].ID != "fast" || responses[1].ID != "slow" || responses[1].Result != "waited" {
+		t.Errorf("responses = %+v, want fast then slow", responses)
+	}
+}
+
+func TestServeWithoutIDsIsSequential(t *testing.T) {
+	srv := NewServer()
+	srv.Workers = 4
+	var (
+		running int
+		maxSeen int
+	)
+	srv.Handle("step", func(ctx context.Context, req Request) (any, error) {
+		running++
+		if running > maxSeen {
+			maxSeen = running
+		}
+		time.Sleep(time.Millisecond)
+		running--
+		var n int
+		if err := DecodePayload(req, &n); err != nil {
+			return nil, err
+		}
+		return n, nil
+	})
+
+	in := strings.NewReader(`{"method":"step","payload":1}
+{"method":"step","payload":2}
+{"method":"step","payload":3}
+`)
+	var out bytes.Buffer
+	if err := srv.Serve(context.Background(), in, &out); err != nil {
+		t.Fatalf("Serve() error = %v", err)
+	}
+
+	responses := decodeResponses(t, &out)
+	for i, resp := range responses {
+		if resp.Result != float64(i+1) {
+			t.Errorf("response %d = %+v, want %d", i, resp, i+1)
+		}
+	}
+	if maxSeen != 1 {
+		t.Errorf("requests without IDs ran concurrently: %d at once", maxSeen)
+	}
+}
+
+func TestServeLogsRequests(t *testing.T) {
+	var logs bytes.Buffer
+	srv := NewServer()
+	srv.Logger = logging.NewLogger(&logs, slog.LevelInfo)
+	srv.Handle("list", func(ctx context.Context, req Request) (any, error) {
+		// Handler logs carry the request's method and ID.
+		srv.Logger.InfoContext(ctx, "listing")
+		return []string{"a", "b"}, nil
+	})
+	in := strings.NewReader(`{"id":"1","method":"list"}
+{"id":"2","method":"missing"}
+`)
+	var out bytes.Buffer
+	if err := srv.Serve(context.Background(), in, &out); err != nil {
+		t.Fatalf("Serve() error = %v", err)
+	}
+
+	records := map[string]map[string]any{}
+	dec := json.NewDecoder(&logs)
+	for dec.More() {
+		var record map[string]any
+		if err := dec.Decode(&record); err != nil {
+			t.Fatalf("decode log record: %v", err)
+		}
+		records[record["msg"].(string)] = record
+	}
+	if r := records["listing"]; r["method"] != "list" || r["requestId"] != "1" {
+		t.Errorf("handler record = %v", r)
+	}
+	if r := records["request completed"]; r["level"] != "INFO" || r["requestId"] != "1" || r["items"] != 2.0 || r["latency"] == nil {
+		t.Errorf("completed record = %v", r)
+	}
+	if r := records["request failed"]; r["level"] != "WARN" || r["method"] != "missing" || r["code"] != "invalid_argument" {
+		t.Errorf("failed record = %v", r)
+	}
+}
+
+func TestDispatchRecoversPanic(t *testing.T) {
+	var logs bytes.Buffer
+	srv := NewServer()
+	srv.Logger = logging.NewLogger(&logs, slog.LevelInfo)
+	srv.Handle("panic", func(ctx context.Context, req Request) (any, error) {
+		var values []int
+		return values[1], nil
+	})
+
+	resp := srv.Dispatch(context.Background(), Request{ID: "1", Method: "panic"})
+	if resp.Error == nil || resp.Error.Code != apierr.Internal || !strings.Contains(resp.Error.Message, "panic panicked") {
+		t.Errorf("response = %+v, want internal error", resp)
+	}
+	if line := logs.String(); !strings.Contains(line, `"msg":"handler panicked"`) || !strings.Contains(line, "runtime/debug.Stack") {
+		t.Errorf("logs = %s, want the panic and its stack", line)
+	}
+
+	// Served requests carry the method and ID in the context, once.
+	logs.Reset()
+	var out bytes.Buffer
+	if err := srv.Serve(context.Background(), strings.NewReader(`{"id":"1","method":"panic"}`+"\n"), &out); err != nil {
+		t.Fatalf("Serve() error = %v", err)
+	}
+	line, _, _ := strings.Cut(logs.String(), "\n")
+	if strings.Count(line, `"method":`) != 1 || strings.Count(line, `"requestId":`) != 1 {
+		t.Errorf("panic record = %s, want method and requestId once", line)
+	}
+}
+
+func TestServeShutdown(t *testing.T) {
+	srv := NewServer()
+	srv.Logger = nil
+	srv.DefaultTimeout = time.Hour
+	srv.ShutdownGrace = 200 * time.Millisecond
+	started := make(chan struct{}, 2)
+	srv.Handle("quick", func(ctx context.Context, req Request) (any, error) {
+		started <- struct{}{}
+		time.Sleep(10 * time.Millisecond)
+		return "done", nil
+	})
+	srv.Handle("block", func(ctx context.Context, req Request) (any, error) {
+		started <- struct{}{}
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	in, feed := io.Pipe()
+	defer feed.Close()
+	var out bytes.Buffer
+	served := make(chan error, 1)
+	go func() {
+		served <- srv.Serve(ctx, in, &out)
+	}()
+
+	fmt.Fprintln(feed, `{"id":"quick","method":"quick"}`)
+	fmt.Fprintln(feed, `{"id":"block","method":"block"}`)
+	<-started
+	<-started
+	cancel()
+	// Requests read after shutdown begins are rejected.
+	fmt.Fprintln(feed, `{"id":"late","method":"quick"}`)
+
+	if err := <-served; err != nil {
+		t.Fatalf("Serve() error = %v", err)
+	}
+	got := map[string]Response{}
+	for _, resp := range decodeResponses(t, &out) {
+		got[resp.ID] = resp
+	}
+	if got["quick"].Result != "done" {
+		t.Errorf("quick = %+v, want it to finish within the grace period", got["quick"])
+	}
+	if err := got["block"].Error; err == nil || err.Code != apierr.Canceled {
+		t.Errorf("block = %+v, want canceled after the grace period", got["block"])
+	}
+	if err := got["late"].Error; err == nil || err.Code != apierr.Unavailable {
+		t.Errorf("late = %+v, want unavailable", got["late"])
+	}
+}